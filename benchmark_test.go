@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -622,3 +623,34 @@ func BenchmarkLogValuerResolution(b *testing.B) {
 		logger.Info("bench", slog.Any("resolved", lv))
 	}
 }
+
+func BenchmarkPIIScannerFormatter(b *testing.B) {
+	b.Run("no_match", func(b *testing.B) {
+		logger := newDiscardLogger(PIIScannerFormatter())
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info("bench", slog.String("details", "the order has been shipped and should arrive tomorrow"))
+		}
+	})
+
+	b.Run("matches", func(b *testing.B) {
+		logger := newDiscardLogger(PIIScannerFormatter())
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info("bench", slog.String("details", "refund sent to foo@example.com, card 4111 1111 1111 1111, from 192.168.1.42"))
+		}
+	})
+
+	b.Run("large_text", func(b *testing.B) {
+		logger := newDiscardLogger(PIIScannerFormatter())
+		text := strings.Repeat("lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 64) + "contact foo@example.com"
+		b.ReportAllocs()
+		b.SetBytes(int64(len(text)))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info("bench", slog.String("details", text))
+		}
+	})
+}
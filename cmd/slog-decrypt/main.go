@@ -0,0 +1,247 @@
+// Command slog-decrypt decrypts the values encrypted by slogformatter.EncryptFormatter
+// in JSON log lines. It works fully offline.
+//
+// Usage:
+//
+//	slog-decrypt -keyring keyring.json [file ...]
+//
+// The keyring file is a JSON object mapping key IDs to base64-encoded AES keys:
+//
+//	{"sec-1": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="}
+//
+// Log lines are read from the files, or from stdin when no file is given, and are
+// written to stdout with every "enc:v1:..." string value decrypted. Lines that are
+// not JSON objects are copied unchanged. Values that cannot be decrypted are left
+// as is, reported on stderr, and make the command exit with status 1.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	slogformatter "github.com/samber/slog-formatter"
+)
+
+func main() {
+	keyringPath := flag.String("keyring", "", "path to the keyring file (JSON object of key ID to base64 AES key)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -keyring keyring.json [file ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *keyringPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	keyring, err := readKeyring(*keyringPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "slog-decrypt:", err)
+		os.Exit(2)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	failed := false
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	for _, input := range inputs {
+		ok, err := decryptInput(input, keyring, out)
+		if err != nil {
+			out.Flush()
+			fmt.Fprintln(os.Stderr, "slog-decrypt:", err)
+			os.Exit(2)
+		}
+		failed = failed || !ok
+	}
+
+	if failed {
+		out.Flush()
+		os.Exit(1)
+	}
+}
+
+func readKeyring(path string) (slogformatter.Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeyring(data)
+}
+
+func parseKeyring(data []byte) (slogformatter.Keyring, error) {
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("invalid keyring: %w", err)
+	}
+
+	keyring := make(slogformatter.Keyring, len(encoded))
+	for keyID, key := range encoded {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keyring: key %q: %w", keyID, err)
+		}
+		keyring[keyID] = decoded
+	}
+
+	return keyring, nil
+}
+
+func decryptInput(input string, keyring slogformatter.Keyring, w io.Writer) (bool, error) {
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return decryptLines(input, r, keyring, w)
+}
+
+// decryptLines copies lines from r to w, decrypting the JSON lines. It returns
+// false when some values could not be decrypted.
+func decryptLines(name string, r io.Reader, keyring slogformatter.Keyring, w io.Writer) (bool, error) {
+	reader := bufio.NewReader(r)
+	ok := true
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			content := bytes.TrimRight(line, "\r\n")
+			decrypted, errs := decryptLine(content, keyring)
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "slog-decrypt: %s:%d: %v\n", name, lineNumber, e)
+				ok = false
+			}
+
+			if _, werr := w.Write(decrypted); werr != nil {
+				return ok, werr
+			}
+			if _, werr := w.Write(line[len(content):]); werr != nil {
+				return ok, werr
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return ok, nil
+		}
+		if err != nil {
+			return ok, err
+		}
+	}
+}
+
+// decryptLine decrypts the encrypted string values of a JSON object, preserving
+// the order of the keys. Lines that are not JSON objects are returned unchanged.
+func decryptLine(line []byte, keyring slogformatter.Keyring) ([]byte, []error) {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		return line, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+
+	d := decrypter{keyring: keyring}
+	if err := d.rewrite(dec); err != nil {
+		return line, []error{err}
+	}
+
+	return d.buf.Bytes(), d.errs
+}
+
+type decrypter struct {
+	keyring slogformatter.Keyring
+	buf     bytes.Buffer
+	errs    []error
+}
+
+func (d *decrypter) rewrite(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		return d.rewriteComposite(dec, t)
+	case string:
+		if slogformatter.IsEncrypted(t) {
+			plaintext, err := slogformatter.Decrypt(t, d.keyring)
+			if err != nil {
+				d.errs = append(d.errs, err)
+			} else {
+				t = plaintext
+			}
+		}
+		return d.writeString(t)
+	case json.Number:
+		d.buf.WriteString(t.String())
+	case bool:
+		fmt.Fprint(&d.buf, t)
+	case nil:
+		d.buf.WriteString("null")
+	}
+
+	return nil
+}
+
+func (d *decrypter) rewriteComposite(dec *json.Decoder, delim json.Delim) error {
+	d.buf.WriteByte(byte(delim))
+
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			d.buf.WriteByte(',')
+		}
+
+		if delim == '{' {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if err := d.writeString(key.(string)); err != nil {
+				return err
+			}
+			d.buf.WriteByte(':')
+		}
+
+		if err := d.rewrite(dec); err != nil {
+			return err
+		}
+	}
+
+	// closing delimiter
+	end, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	d.buf.WriteByte(byte(end.(json.Delim)))
+
+	return nil
+}
+
+func (d *decrypter) writeString(s string) error {
+	var tmp bytes.Buffer
+	enc := json.NewEncoder(&tmp)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	d.buf.Write(bytes.TrimSuffix(tmp.Bytes(), []byte("\n")))
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	slogformatter "github.com/samber/slog-formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestParseKeyring(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	keyring, err := parseKeyring([]byte(`{"sec-1":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="}`))
+	is.NoError(err)
+	is.Equal(slogformatter.Keyring{"sec-1": testKey}, keyring)
+
+	_, err = parseKeyring([]byte(`{"sec-1":"!!!"}`))
+	is.Error(err)
+	_, err = parseKeyring([]byte(`[]`))
+	is.Error(err)
+}
+
+func TestDecryptLine(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	keyring := slogformatter.Keyring{"sec-1": testKey}
+	email, err := slogformatter.Encrypt("foo<bar>@example.com", slogformatter.EncryptOptions{Key: testKey, KeyID: "sec-1"})
+	is.NoError(err)
+	unknown, err := slogformatter.Encrypt("foobar", slogformatter.EncryptOptions{Key: testKey, KeyID: "sec-2"})
+	is.NoError(err)
+
+	line := `{"time":"2023-04-10T14:00:00Z","msg":"hello","user":{"email":"` + email + `","age":42,"tags":["a",null,true]}}`
+	out, errs := decryptLine([]byte(line), keyring)
+	is.Empty(errs)
+	is.Equal(`{"time":"2023-04-10T14:00:00Z","msg":"hello","user":{"email":"foo<bar>@example.com","age":42,"tags":["a",null,true]}}`, string(out))
+
+	line = `{"a":"` + unknown + `"}`
+	out, errs = decryptLine([]byte(line), keyring)
+	is.Len(errs, 1)
+	is.Equal(line, string(out))
+
+	for _, line := range []string{"", "plain text", `{"broken":`, `["` + email + `"]`} {
+		out, errs = decryptLine([]byte(line), keyring)
+		is.Empty(errs)
+		is.Equal(line, string(out))
+	}
+}
+
+func TestDecryptLines(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	keyring := slogformatter.Keyring{"sec-1": testKey}
+	secret, err := slogformatter.Encrypt("secret", slogformatter.EncryptOptions{Key: testKey, KeyID: "sec-1"})
+	is.NoError(err)
+
+	input := "{\"a\":\"" + secret + "\"}\r\nnot json\n{\"b\":1}"
+	var out bytes.Buffer
+	ok, err := decryptLines("test", strings.NewReader(input), keyring, &out)
+	is.NoError(err)
+	is.True(ok)
+	is.Equal("{\"a\":\"secret\"}\r\nnot json\n{\"b\":1}", out.String())
+}
@@ -81,6 +81,13 @@ func FormatByType[T any](formatter func(T) slog.Value) Formatter {
 // FormatByKind pass attributes matching `slog.Kind` into a formatter.
 // This function performs recursive lookup through nested groups to find matching kinds.
 func FormatByKind(kind slog.Kind, formatter func(slog.Value) slog.Value) Formatter {
+	return formatByKindPath(kind, func(_ []string, _ string, v slog.Value) slog.Value {
+		return formatter(v)
+	})
+}
+
+// formatByKindPath is FormatByKind, with the groups and key of the attribute.
+func formatByKindPath(kind slog.Kind, formatter func([]string, string, slog.Value) slog.Value) Formatter {
 	var formatRecursive func([]string, slog.Attr) (slog.Value, bool)
 	formatRecursive = func(groups []string, attr slog.Attr) (slog.Value, bool) {
 		value := attr.Value
@@ -108,7 +115,7 @@ func FormatByKind(kind slog.Kind, formatter func(slog.Value) slog.Value) Formatt
 		}
 
 		if value.Kind() == kind {
-			return formatter(value), true
+			return formatter(groups, attr.Key, value), true
 		}
 
 		return value, false
@@ -120,12 +127,19 @@ func FormatByKind(kind slog.Kind, formatter func(slog.Value) slog.Value) Formatt
 // FormatByKey pass attributes matching key into a formatter.
 // This function performs recursive lookup through nested groups to find matching keys.
 func FormatByKey(key string, formatter func(slog.Value) slog.Value) Formatter {
+	return formatByKeyPath(key, func(_ []string, _ string, v slog.Value) slog.Value {
+		return formatter(v)
+	})
+}
+
+// formatByKeyPath is FormatByKey, with the groups of the attribute.
+func formatByKeyPath(key string, formatter func([]string, string, slog.Value) slog.Value) Formatter {
 	var formatRecursive func([]string, slog.Attr) (slog.Value, bool)
 	formatRecursive = func(groups []string, attr slog.Attr) (slog.Value, bool) {
 		value := attr.Value
 
 		if attr.Key == key {
-			return formatter(value), true
+			return formatter(groups, attr.Key, value), true
 		}
 
 		if value.Kind() == slog.KindGroup {
@@ -218,3 +232,19 @@ func FormatByGroupKeyType[T any](groups []string, key string, formatter func(T)
 		return value, false
 	}
 }
+
+// mapLeaves applies transform to the string representation of a value, or to
+// each leaf value of a group, recursively. Leaves become strings.
+func mapLeaves(v slog.Value, transform func(string) string) slog.Value {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return slog.StringValue(transform(v.String()))
+	}
+
+	group := v.Group()
+	attrs := make([]slog.Attr, len(group))
+	for i, item := range group {
+		attrs[i] = slog.Attr{Key: item.Key, Value: mapLeaves(item.Value, transform)}
+	}
+	return slog.GroupValue(attrs...)
+}
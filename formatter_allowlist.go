@@ -0,0 +1,178 @@
+package slogformatter
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"log/slog"
+)
+
+// AllowlistFormatterOptions configures AllowlistFormatterWithOptions.
+type AllowlistFormatterOptions struct {
+	// Allow lists the attributes passing through unchanged. A pattern containing
+	// a "." matches the full path of an attribute, from the outermost group
+	// ("http.request.method"); other patterns match the attribute key at any depth
+	// ("request_id"). Patterns use path.Match syntax and are case-insensitive.
+	// Allowing a group allows all its attributes.
+	Allow []string
+	// Drop removes the other attributes instead of masking them.
+	Drop bool
+	// KeepType masks the other attributes with the zero value of their kind:
+	// numbers become 0, booleans false, durations 0 and times the zero time.
+	// Strings and other values are replaced by "*******".
+	KeepType bool
+	// Audit counts the masked and dropped values under the "allowlist" rule. Optional.
+	Audit *RedactionAudit
+}
+
+type allowlist struct {
+	keys     []string
+	paths    []string
+	drop     bool
+	keepType bool
+	audit    *RedactionAudit
+}
+
+func newAllowlist(opts AllowlistFormatterOptions) allowlist {
+	a := allowlist{
+		drop:     opts.Drop,
+		keepType: opts.KeepType,
+		audit:    opts.Audit,
+	}
+
+	for _, pattern := range opts.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic("slog-formatter: invalid allowlist pattern: " + pattern)
+		}
+
+		if strings.Contains(pattern, ".") {
+			a.paths = append(a.paths, strings.ToLower(pattern))
+		} else {
+			a.keys = append(a.keys, strings.ToLower(pattern))
+		}
+	}
+
+	return a
+}
+
+func (a allowlist) allowed(groups []string, key string) bool {
+	lower := strings.ToLower(key)
+	for _, pattern := range a.keys {
+		if ok, _ := path.Match(pattern, lower); ok {
+			return true
+		}
+	}
+
+	if len(a.paths) == 0 {
+		return false
+	}
+
+	fullPath := lower
+	if len(groups) > 0 {
+		fullPath = strings.ToLower(strings.Join(groups, ".")) + "." + lower
+	}
+	for _, pattern := range a.paths {
+		if ok, _ := path.Match(pattern, fullPath); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a allowlist) format(groups []string, attr slog.Attr) (slog.Value, bool) {
+	if a.allowed(groups, attr.Key) {
+		return attr.Value, false
+	}
+
+	value := attr.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		// groups with an empty key are inlined into their parent
+		nestedGroups := groups
+		if attr.Key != "" {
+			nestedGroups = make([]string, len(groups)+1)
+			copy(nestedGroups, groups)
+			nestedGroups[len(groups)] = attr.Key
+		}
+
+		attrs := make([]slog.Attr, 0, len(value.Group()))
+		for _, nestedAttr := range value.Group() {
+			v, ok := a.format(nestedGroups, nestedAttr)
+			if ok && a.drop && v.Kind() == slog.KindGroup && len(v.Group()) == 0 {
+				continue
+			}
+			attrs = append(attrs, slog.Attr{Key: nestedAttr.Key, Value: v})
+		}
+
+		return slog.GroupValue(attrs...), true
+	}
+
+	if a.audit != nil {
+		a.audit.add("allowlist", joinPath(groups, attr.Key))
+	}
+
+	if a.drop {
+		// handlers ignore attributes holding an empty group
+		return slog.GroupValue(), true
+	}
+
+	return a.mask(value), true
+}
+
+func (a allowlist) mask(v slog.Value) slog.Value {
+	if !a.keepType {
+		return slog.StringValue("*******")
+	}
+
+	switch v.Kind() {
+	case slog.KindInt64:
+		return slog.Int64Value(0)
+	case slog.KindUint64:
+		return slog.Uint64Value(0)
+	case slog.KindFloat64:
+		return slog.Float64Value(0)
+	case slog.KindBool:
+		return slog.BoolValue(false)
+	case slog.KindDuration:
+		return slog.DurationValue(0)
+	case slog.KindTime:
+		return slog.TimeValue(time.Time{})
+	default:
+		return slog.StringValue("*******")
+	}
+}
+
+// AllowlistFormatter masks every attribute that is not allowed by one of the
+// patterns. See AllowlistFormatterWithOptions.
+func AllowlistFormatter(allow ...string) Formatter {
+	return AllowlistFormatterWithOptions(AllowlistFormatterOptions{Allow: allow})
+}
+
+// AllowlistFormatterWithOptions implements a default-deny policy: only the allowed
+// attributes pass through unchanged, the others are masked or dropped. It should be
+// the last formatter of a handler, so that it sees the output of the others.
+//
+// Example:
+//
+//	"request_id": "42",
+//	"http": {
+//	  "method": "GET",
+//	  "token": "abcd",
+//	  "status": 200
+//	}
+//
+// passed to AllowlistFormatter("request_id", "http.method"), will be transformed into:
+//
+//	"request_id": "42",
+//	"http": {
+//	  "method": "GET",
+//	  "token": "*******",
+//	  "status": "*******"
+//	}
+//
+// It panics when a pattern is malformed.
+func AllowlistFormatterWithOptions(opts AllowlistFormatterOptions) Formatter {
+	return newAllowlist(opts).format
+}
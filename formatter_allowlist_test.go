@@ -0,0 +1,120 @@
+package slogformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowlistFormatter(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := AllowlistFormatter("request_id", "http.method", "HTTP.Route", "user.*")
+
+	v, ok := formatter(nil, slog.String("request_id", "42"))
+	is.False(ok)
+	is.Equal("42", v.String())
+
+	v, ok = formatter(nil, slog.String("token", "abcd"))
+	is.True(ok)
+	is.Equal("*******", v.String())
+
+	// keys match at any depth
+	v, ok = formatter([]string{"job"}, slog.String("request_id", "42"))
+	is.False(ok)
+	is.Equal("42", v.String())
+
+	v, ok = formatter(nil, slog.Group("http",
+		slog.String("method", "GET"),
+		slog.String("route", "/users/{id}"),
+		slog.String("token", "abcd"),
+		slog.Int("status", 200),
+		slog.Group("headers", slog.String("method", "POST")),
+	))
+	is.True(ok)
+	is.Equal("[method=GET route=/users/{id} token=******* status=******* headers=[method=*******]]", v.String())
+
+	// paths include the groups of the handler
+	v, ok = formatter([]string{"http"}, slog.String("method", "GET"))
+	is.False(ok)
+	is.Equal("GET", v.String())
+	v, ok = formatter([]string{"outer", "http"}, slog.String("method", "GET"))
+	is.True(ok)
+	is.Equal("*******", v.String())
+
+	// allowed subtree
+	v, ok = formatter(nil, slog.Group("user", slog.String("id", "42"), slog.Group("address", slog.String("city", "Paris"))))
+	is.True(ok)
+	is.Equal("[id=42 address=[city=Paris]]", v.String())
+
+	// inlined groups
+	v, ok = formatter([]string{"http"}, slog.Group("", slog.String("method", "GET"), slog.String("token", "abcd")))
+	is.True(ok)
+	is.Equal("[method=GET token=*******]", v.String())
+
+	is.PanicsWithValue("slog-formatter: invalid allowlist pattern: [", func() {
+		AllowlistFormatter("[")
+	})
+}
+
+func TestAllowlistFormatter_KeepType(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := AllowlistFormatterWithOptions(AllowlistFormatterOptions{KeepType: true})
+
+	tests := []struct {
+		value    slog.Value
+		expected slog.Value
+	}{
+		{slog.StringValue("foobar"), slog.StringValue("*******")},
+		{slog.IntValue(42), slog.Int64Value(0)},
+		{slog.Uint64Value(42), slog.Uint64Value(0)},
+		{slog.Float64Value(4.2), slog.Float64Value(0)},
+		{slog.BoolValue(true), slog.BoolValue(false)},
+		{slog.DurationValue(time.Second), slog.DurationValue(0)},
+		{slog.TimeValue(time.Now()), slog.TimeValue(time.Time{})},
+		{slog.AnyValue([]string{"foobar"}), slog.StringValue("*******")},
+	}
+
+	for _, test := range tests {
+		v, ok := formatter(nil, slog.Attr{Key: "key", Value: test.value})
+		is.True(ok)
+		is.True(test.expected.Equal(v), test.value.String())
+	}
+}
+
+func TestAllowlistFormatter_Drop(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(
+		NewFormatterHandler(
+			AllowlistFormatterWithOptions(AllowlistFormatterOptions{
+				Allow: []string{"request_id", "http.method"},
+				Drop:  true,
+			}),
+		)(slog.NewJSONHandler(&buf, nil)),
+	)
+
+	logger.
+		With("request_id", "42", "email", "foobar@example.com").
+		WithGroup("http").
+		Info("hello", "method", "GET", "token", "abcd", slog.Group("headers", slog.String("cookie", "secret")))
+
+	var record map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &record))
+	delete(record, "time")
+	is.Equal(map[string]any{
+		"level":      "INFO",
+		"msg":        "hello",
+		"request_id": "42",
+		"http":       map[string]any{"method": "GET"},
+	}, record)
+}
@@ -0,0 +1,167 @@
+package slogformatter
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"log/slog"
+)
+
+// shredPrefix starts every ciphertext produced by CryptoShreddingFormatter,
+// followed by the opaque key ID of the subject: "shred:v1:<key ID>:<base64>".
+const shredPrefix = "shred:v1:"
+
+// CryptoShreddingOptions configures CryptoShreddingFormatter.
+type CryptoShreddingOptions struct {
+	// KeyStore stores the data key of each subject. Required.
+	KeyStore KeyStore
+	// SubjectKey is the key of the attribute holding the subject ID, such as
+	// "user_id". It must be a sibling of the encrypted attributes. Required.
+	SubjectKey string
+	// Fields are the key patterns of the encrypted sibling attributes, with the
+	// path.Match syntax. Matching is case-insensitive. When nil, every sibling is
+	// encrypted.
+	Fields []string
+}
+
+type cryptoShredder struct {
+	store      KeyStore
+	subjectKey string
+	fields     []string
+}
+
+func newCryptoShredder(opts CryptoShreddingOptions) cryptoShredder {
+	if opts.KeyStore == nil {
+		panic("slog-formatter: crypto-shredding key store is required")
+	}
+	if opts.SubjectKey == "" {
+		panic("slog-formatter: crypto-shredding subject key is required")
+	}
+
+	var fields []string
+	if opts.Fields != nil {
+		fields = make([]string, 0, len(opts.Fields))
+		for _, pattern := range opts.Fields {
+			if _, err := path.Match(pattern, ""); err != nil {
+				panic("slog-formatter: invalid crypto-shredding pattern: " + pattern)
+			}
+			fields = append(fields, strings.ToLower(pattern))
+		}
+	}
+
+	return cryptoShredder{
+		store:      opts.KeyStore,
+		subjectKey: opts.SubjectKey,
+		fields:     fields,
+	}
+}
+
+func (c cryptoShredder) isField(key string) bool {
+	if key == c.subjectKey {
+		return false
+	}
+	if c.fields == nil {
+		return true
+	}
+
+	key = strings.ToLower(key)
+	for _, pattern := range c.fields {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c cryptoShredder) format(v slog.Value) slog.Value {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		// no sibling can hold the subject
+		return slog.StringValue("*******")
+	}
+
+	group := v.Group()
+
+	subject := ""
+	for _, attr := range group {
+		if attr.Key == c.subjectKey {
+			subject = attr.Value.Resolve().String()
+			break
+		}
+	}
+
+	// fields are masked when they cannot be encrypted
+	shred := func(string) string { return "*******" }
+	if subject != "" {
+		if keyID, key, err := c.store.GetOrCreateKey(subject); err == nil && isKeyID(keyID) {
+			if aead, err := newAEAD(key); err == nil {
+				shred = encrypter{aead: aead, header: shredPrefix + keyID}.encryptOrMask
+			}
+		}
+	}
+
+	attrs := make([]slog.Attr, len(group))
+	for i, attr := range group {
+		if c.isField(attr.Key) {
+			attrs[i] = slog.Attr{Key: attr.Key, Value: mapLeaves(attr.Value, shred)}
+		} else {
+			attrs[i] = attr
+		}
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// CryptoShreddingFormatter encrypts the attributes of a group with the data key
+// of the subject identified by a sibling attribute, so that deleting the key of
+// a subject from the KeyStore makes its data unreadable, without rewriting the
+// logs. Attributes are masked when the group has no subject or when the key
+// store fails, and values that are not groups are masked too, since they have
+// no subject. Non-string values are encrypted from their string representation.
+//
+// Example:
+//
+//	"user": {
+//	  "user_id": "42",
+//	  "email": "foobar@example.com"
+//	}
+//
+// passed to CryptoShreddingFormatter("user", CryptoShreddingOptions{KeyStore: store, SubjectKey: "user_id"}),
+// will be transformed into:
+//
+//	"user": {
+//	  "user_id": "42",
+//	  "email": "shred:v1:5f0c3e7a91d24b68a3c1e0f9b7d26a44:Oh1iHsu5vSIbHB5nSmqh8D7PCYUUzRVn..."
+//	}
+//
+// It panics when the options are invalid.
+func CryptoShreddingFormatter(key string, opts CryptoShreddingOptions) Formatter {
+	return FormatByKey(key, newCryptoShredder(opts).format)
+}
+
+// DecryptShredded decrypts a value produced by CryptoShreddingFormatter. It returns
+// ErrSubjectKeyNotFound when the key of the subject has been deleted, even if the
+// subject has a new key.
+func DecryptShredded(ciphertext string, store KeyStore) (string, error) {
+	if !strings.HasPrefix(ciphertext, shredPrefix) {
+		return "", errors.New("slog-formatter: not a crypto-shredded value")
+	}
+
+	keyID, payload, ok := strings.Cut(strings.TrimPrefix(ciphertext, shredPrefix), ":")
+	if !ok || keyID == "" || !isKeyID(keyID) {
+		return "", errors.New("slog-formatter: malformed crypto-shredded value")
+	}
+
+	key, err := store.Key(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	return encrypter{aead: aead, header: shredPrefix + keyID}.decrypt(payload)
+}
@@ -0,0 +1,145 @@
+package slogformatter
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCryptoShreddingFormatter(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	store := NewInMemoryKeyStore()
+	formatter := CryptoShreddingFormatter("user", CryptoShreddingOptions{
+		KeyStore:   store,
+		SubjectKey: "user_id",
+		Fields:     []string{"email", "Address"},
+	})
+
+	v, ok := formatter(nil, slog.Group("user",
+		slog.String("user_id", "42"),
+		slog.String("email", "foobar@example.com"),
+		slog.Group("address", slog.String("city", "Paris")),
+		slog.String("plan", "pro"),
+	))
+	is.True(ok)
+
+	group := v.Group()
+	is.Equal("42", group[0].Value.String())
+	keyID, _, err := store.GetOrCreateKey("42")
+	is.NoError(err)
+	is.True(strings.HasPrefix(group[1].Value.String(), "shred:v1:"+keyID+":"))
+	is.Equal("pro", group[3].Value.String())
+
+	plaintext, err := DecryptShredded(group[1].Value.String(), store)
+	is.NoError(err)
+	is.Equal("foobar@example.com", plaintext)
+	plaintext, err = DecryptShredded(group[2].Value.Group()[0].Value.String(), store)
+	is.NoError(err)
+	is.Equal("Paris", plaintext)
+
+	// right to be forgotten
+	is.NoError(store.DeleteKey("42"))
+	_, err = DecryptShredded(group[1].Value.String(), store)
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+
+	// a new key is created for the next records
+	v, _ = formatter(nil, slog.Group("user", slog.Int("user_id", 42), slog.String("email", "foobar@example.com")))
+	plaintext, err = DecryptShredded(v.Group()[1].Value.String(), store)
+	is.NoError(err)
+	is.Equal("foobar@example.com", plaintext)
+	_, err = DecryptShredded(group[1].Value.String(), store)
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+}
+
+func TestCryptoShreddingFormatter_AllFields(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	store := NewInMemoryKeyStore()
+	formatter := CryptoShreddingFormatter("user", CryptoShreddingOptions{KeyStore: store, SubjectKey: "id"})
+
+	v, _ := formatter(nil, slog.Group("user", slog.String("name", "John"), slog.String("id", "user/1"), slog.Int("age", 42)))
+	is.Equal("user/1", v.Group()[1].Value.String())
+
+	plaintext, err := DecryptShredded(v.Group()[0].Value.String(), store)
+	is.NoError(err)
+	is.Equal("John", plaintext)
+	plaintext, err = DecryptShredded(v.Group()[2].Value.String(), store)
+	is.NoError(err)
+	is.Equal("42", plaintext)
+
+	// other subjects are not affected
+	other, _ := formatter(nil, slog.Group("user", slog.String("id", "user/2"), slog.String("name", "Jane")))
+	is.NoError(store.DeleteKey("user/1"))
+	plaintext, err = DecryptShredded(other.Group()[1].Value.String(), store)
+	is.NoError(err)
+	is.Equal("Jane", plaintext)
+}
+
+type failingKeyStore struct {
+	KeyStore
+}
+
+func (failingKeyStore) GetOrCreateKey(string) (string, []byte, error) {
+	return "", nil, errors.New("unavailable")
+}
+
+func TestCryptoShreddingFormatter_Masking(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	// missing subject
+	formatter := CryptoShreddingFormatter("user", CryptoShreddingOptions{KeyStore: NewInMemoryKeyStore(), SubjectKey: "user_id"})
+	v, ok := formatter(nil, slog.Group("user", slog.String("email", "foobar@example.com")))
+	is.True(ok)
+	is.Equal("[email=*******]", v.String())
+
+	// key store failure
+	formatter = CryptoShreddingFormatter("user", CryptoShreddingOptions{KeyStore: failingKeyStore{}, SubjectKey: "user_id"})
+	v, _ = formatter(nil, slog.Group("user", slog.String("user_id", "42"), slog.String("email", "foobar@example.com")))
+	is.Equal("[user_id=42 email=*******]", v.String())
+
+	// not a group: there is no subject
+	v, _ = formatter(nil, slog.String("user", "foobar@example.com"))
+	is.Equal("*******", v.String())
+	v, _ = formatter(nil, slog.Any("user", struct{ Email string }{Email: "foobar@example.com"}))
+	is.Equal("*******", v.String())
+
+	is.PanicsWithValue("slog-formatter: crypto-shredding key store is required", func() {
+		CryptoShreddingFormatter("user", CryptoShreddingOptions{SubjectKey: "user_id"})
+	})
+	is.PanicsWithValue("slog-formatter: crypto-shredding subject key is required", func() {
+		CryptoShreddingFormatter("user", CryptoShreddingOptions{KeyStore: NewInMemoryKeyStore()})
+	})
+	is.PanicsWithValue("slog-formatter: invalid crypto-shredding pattern: [", func() {
+		CryptoShreddingFormatter("user", CryptoShreddingOptions{KeyStore: NewInMemoryKeyStore(), SubjectKey: "user_id", Fields: []string{"["}})
+	})
+}
+
+func TestDecryptShredded_Errors(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	store := NewInMemoryKeyStore()
+
+	_, err := DecryptShredded("foobar", store)
+	is.EqualError(err, "slog-formatter: not a crypto-shredded value")
+	_, err = DecryptShredded("shred:v1:NDI", store)
+	is.EqualError(err, "slog-formatter: malformed crypto-shredded value")
+	_, err = DecryptShredded("shred:v1:!!:abcd", store)
+	is.EqualError(err, "slog-formatter: malformed crypto-shredded value")
+	_, err = DecryptShredded("shred:v1::abcd", store)
+	is.EqualError(err, "slog-formatter: malformed crypto-shredded value")
+	_, err = DecryptShredded("shred:v1:5f0c3e7a91d24b68:abcd", store)
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+
+	keyID, _, err := store.GetOrCreateKey("42")
+	is.NoError(err)
+	_, err = DecryptShredded("shred:v1:"+keyID+":abcd", store)
+	is.EqualError(err, "slog-formatter: malformed encrypted value")
+}
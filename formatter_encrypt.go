@@ -0,0 +1,181 @@
+package slogformatter
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"log/slog"
+)
+
+// encryptPrefix starts every ciphertext produced by EncryptFormatter.
+const encryptPrefix = "enc:v1:"
+
+// EncryptOptions configures the encryption of values.
+type EncryptOptions struct {
+	// Key is the AES key: 16, 24 or 32 bytes long.
+	Key []byte
+	// KeyID identifies Key in the ciphertexts ("enc:v1:<KeyID>:<base64>"), so that
+	// Decrypt can pick the right key from a keyring. It may only contain letters,
+	// digits and "-".
+	KeyID string
+	// Audit counts the attributes encrypted by EncryptFormatter under the "encrypt"
+	// rule. Values encrypted by PIIStrategyEncrypt are counted by PIIFormatterOptions.Audit.
+	// Optional.
+	Audit *RedactionAudit
+}
+
+// Keyring maps key IDs to AES keys. See Decrypt.
+type Keyring map[string][]byte
+
+type encrypter struct {
+	aead   cipher.AEAD
+	header string
+}
+
+func newEncrypter(opts EncryptOptions) encrypter {
+	if opts.KeyID == "" || !isKeyID(opts.KeyID) {
+		panic("slog-formatter: invalid encryption key ID: " + opts.KeyID)
+	}
+
+	aead, err := newAEAD(opts.Key)
+	if err != nil {
+		panic("slog-formatter: " + err.Error())
+	}
+
+	return encrypter{
+		aead:   aead,
+		header: encryptPrefix + opts.KeyID,
+	}
+}
+
+// isKeyID reports whether a key ID only contains letters, digits and "-". It is
+// shared by the encryption, pseudonymization and crypto-shredding formatters.
+func isKeyID(keyID string) bool {
+	return strings.IndexFunc(keyID, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-'
+	}) < 0
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt seals the value with a random nonce. The header is authenticated,
+// so that a ciphertext cannot be moved to another key ID.
+func (e encrypter) encrypt(value string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize(), e.aead.NonceSize()+len(value)+e.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, []byte(value), []byte(e.header))
+	return e.header + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt opens a payload sealed by encrypt.
+func (e encrypter) decrypt(payload string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < e.aead.NonceSize() {
+		return "", errMalformedCiphertext
+	}
+
+	nonce, sealed := sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():]
+	plaintext, err := e.aead.Open(nil, nonce, sealed, []byte(e.header))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// encryptOrMask encrypts the value, or returns "*******" when encryption fails.
+func (e encrypter) encryptOrMask(value string) string {
+	ciphertext, err := e.encrypt(value)
+	if err != nil {
+		return "*******"
+	}
+	return ciphertext
+}
+
+var errMalformedCiphertext = errors.New("slog-formatter: malformed encrypted value")
+
+// Encrypt encrypts a value with AES-GCM into "enc:v1:<kid>:<base64>".
+//
+// It panics when the options are invalid.
+func Encrypt(value string, opts EncryptOptions) (string, error) {
+	return newEncrypter(opts).encrypt(value)
+}
+
+// IsEncrypted reports whether s has been produced by Encrypt or EncryptFormatter.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encryptPrefix)
+}
+
+// Decrypt decrypts a value produced by Encrypt or EncryptFormatter, with the key
+// of the keyring matching its key ID.
+func Decrypt(ciphertext string, keyring Keyring) (string, error) {
+	if !IsEncrypted(ciphertext) {
+		return "", errors.New("slog-formatter: not an encrypted value")
+	}
+
+	keyID, payload, ok := strings.Cut(strings.TrimPrefix(ciphertext, encryptPrefix), ":")
+	if !ok {
+		return "", errMalformedCiphertext
+	}
+
+	key, ok := keyring[keyID]
+	if !ok {
+		return "", errors.New("slog-formatter: unknown key ID: " + keyID)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	return encrypter{aead: aead, header: encryptPrefix + keyID}.decrypt(payload)
+}
+
+// PIIStrategyEncrypt replaces values by their AES-GCM ciphertext. Non-string
+// values are encrypted from their string representation. Values are replaced
+// by "*******" when encryption fails.
+//
+// It panics when the options are invalid.
+func PIIStrategyEncrypt(opts EncryptOptions) PIIStrategy {
+	e := newEncrypter(opts)
+	return func(v slog.Value) slog.Value {
+		return slog.StringValue(e.encryptOrMask(v.String()))
+	}
+}
+
+// EncryptFormatter encrypts any value under provided key with AES-GCM, so that
+// only the owners of the key can read it. Nested values of groups are encrypted
+// one by one. Use Decrypt or cmd/slog-decrypt to read the values.
+//
+// Example:
+//
+//	"email": "foobar@example.com"
+//
+// passed to EncryptFormatter("email", EncryptOptions{Key: key, KeyID: "sec1"}),
+// will be transformed into:
+//
+//	"email": "enc:v1:sec1:Oh1iHsu5vSIbHB5nSmqh8D7PCYUUzRVn..."
+//
+// It panics when the options are invalid.
+func EncryptFormatter(key string, opts EncryptOptions) Formatter {
+	e := newEncrypter(opts)
+	return formatByKeyPath(key, func(groups []string, key string, v slog.Value) slog.Value {
+		if opts.Audit != nil {
+			opts.Audit.add("encrypt", joinPath(groups, key))
+		}
+		return mapLeaves(v, e.encryptOrMask)
+	})
+}
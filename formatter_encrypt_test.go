@@ -0,0 +1,136 @@
+package slogformatter
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testEncryptKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptDecrypt(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	opts := EncryptOptions{Key: testEncryptKey, KeyID: "sec-1"}
+	keyring := Keyring{"sec-1": testEncryptKey}
+
+	ciphertext, err := Encrypt("foobar@example.com", opts)
+	is.NoError(err)
+	is.Regexp(regexp.MustCompile(`^enc:v1:sec-1:[A-Za-z0-9_-]+$`), ciphertext)
+	is.True(IsEncrypted(ciphertext))
+	is.NotContains(ciphertext, "foobar")
+
+	plaintext, err := Decrypt(ciphertext, keyring)
+	is.NoError(err)
+	is.Equal("foobar@example.com", plaintext)
+
+	// random nonce
+	other, err := Encrypt("foobar@example.com", opts)
+	is.NoError(err)
+	is.NotEqual(ciphertext, other)
+
+	// empty value
+	ciphertext, err = Encrypt("", opts)
+	is.NoError(err)
+	plaintext, err = Decrypt(ciphertext, keyring)
+	is.NoError(err)
+	is.Equal("", plaintext)
+}
+
+func TestDecryptErrors(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	ciphertext, err := Encrypt("foobar", EncryptOptions{Key: testEncryptKey, KeyID: "sec-1"})
+	is.NoError(err)
+
+	_, err = Decrypt("foobar", Keyring{"sec-1": testEncryptKey})
+	is.EqualError(err, "slog-formatter: not an encrypted value")
+	_, err = Decrypt("enc:v1:sec-1", Keyring{"sec-1": testEncryptKey})
+	is.EqualError(err, "slog-formatter: malformed encrypted value")
+	_, err = Decrypt("enc:v1:sec-1:!!!", Keyring{"sec-1": testEncryptKey})
+	is.EqualError(err, "slog-formatter: malformed encrypted value")
+	_, err = Decrypt(ciphertext, Keyring{"sec-2": testEncryptKey})
+	is.EqualError(err, "slog-formatter: unknown key ID: sec-1")
+	_, err = Decrypt(ciphertext, Keyring{"sec-1": []byte("fedcba9876543210fedcba9876543210")})
+	is.Error(err)
+
+	// the key ID is authenticated
+	moved := strings.Replace(ciphertext, "sec-1", "sec-2", 1)
+	_, err = Decrypt(moved, Keyring{"sec-1": testEncryptKey, "sec-2": testEncryptKey})
+	is.Error(err)
+
+	// tampering
+	tampered := []byte(ciphertext)
+	i := len("enc:v1:sec-1:") + 20
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	_, err = Decrypt(string(tampered), Keyring{"sec-1": testEncryptKey})
+	is.Error(err)
+}
+
+func TestEncryptOptionsValidation(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.PanicsWithValue("slog-formatter: invalid encryption key ID: ", func() {
+		EncryptFormatter("email", EncryptOptions{Key: testEncryptKey})
+	})
+	is.PanicsWithValue("slog-formatter: invalid encryption key ID: sec:1", func() {
+		EncryptFormatter("email", EncryptOptions{Key: testEncryptKey, KeyID: "sec:1"})
+	})
+	is.PanicsWithValue("slog-formatter: crypto/aes: invalid key size 5", func() {
+		EncryptFormatter("email", EncryptOptions{Key: []byte("short"), KeyID: "sec-1"})
+	})
+	is.NotPanics(func() {
+		EncryptFormatter("email", EncryptOptions{Key: testEncryptKey[:16], KeyID: "sec-1"})
+	})
+}
+
+func TestEncryptFormatter(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	keyring := Keyring{"sec-1": testEncryptKey}
+	formatter := EncryptFormatter("user", EncryptOptions{Key: testEncryptKey, KeyID: "sec-1"})
+
+	v, ok := formatter(nil, slog.Group("user", slog.String("email", "foobar@example.com"), slog.Int("age", 42)))
+	is.True(ok)
+	is.Equal(slog.KindGroup, v.Kind())
+	is.Equal("email", v.Group()[0].Key)
+	is.Equal("age", v.Group()[1].Key)
+
+	plaintext, err := Decrypt(v.Group()[0].Value.String(), keyring)
+	is.NoError(err)
+	is.Equal("foobar@example.com", plaintext)
+	plaintext, err = Decrypt(v.Group()[1].Value.String(), keyring)
+	is.NoError(err)
+	is.Equal("42", plaintext)
+
+	_, ok = formatter(nil, slog.String("email", "foobar@example.com"))
+	is.False(ok)
+}
+
+func TestPIIStrategyEncrypt(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := PIIFormatterWithOptions("user", PIIFormatterOptions{
+		Strategy: PIIStrategyEncrypt(EncryptOptions{Key: testEncryptKey, KeyID: "sec-1"}),
+	})
+
+	v, ok := formatter(nil, slog.Group("user", slog.String("id", "42"), slog.String("email", "foobar@example.com")))
+	is.True(ok)
+	is.Equal("42", v.Group()[0].Value.String())
+
+	plaintext, err := Decrypt(v.Group()[1].Value.String(), Keyring{"sec-1": testEncryptKey})
+	is.NoError(err)
+	is.Equal("foobar@example.com", plaintext)
+}
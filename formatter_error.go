@@ -9,6 +9,67 @@ import (
 	"log/slog"
 )
 
+// StacktraceMode defines how ErrorFormatter renders the stacktrace.
+type StacktraceMode int
+
+const (
+	// StacktraceModeString renders the stacktrace as a single newline-joined string.
+	StacktraceModeString StacktraceMode = iota
+	// StacktraceModeFrames renders the stacktrace as a list of StackFrame.
+	StacktraceModeFrames
+	// StacktraceModeGoPanic renders the stacktrace in the text format printed by the Go
+	// runtime on panic, so that tools such as panicparse can parse it.
+	StacktraceModeGoPanic
+)
+
+// StackFrame is a single frame of a stacktrace.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Package  string `json:"package"`
+}
+
+// LogValue implements slog.LogValuer.
+func (f StackFrame) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("function", f.Function),
+		slog.String("file", f.File),
+		slog.Int("line", f.Line),
+		slog.String("package", f.Package),
+	)
+}
+
+// ErrorSchema defines the attribute names emitted by ErrorFormatterWithOptions.
+type ErrorSchema int
+
+const (
+	// ErrorSchemaDefault emits "message", "type" and "stacktrace".
+	ErrorSchemaDefault ErrorSchema = iota
+	// ErrorSchemaECS emits Elastic Common Schema fields: "message", "type" and "stack_trace".
+	// The stacktrace is always a string. Log the error under the "error" key.
+	ErrorSchemaECS
+	// ErrorSchemaGCP emits Google Cloud Error Reporting fields: "@type", "error_message",
+	// "error_type" and "stack_trace". The stacktrace is always in the StacktraceModeGoPanic
+	// format. Log the error under an empty key, so that the fields are inlined at the
+	// root of the log entry.
+	ErrorSchemaGCP
+)
+
+// gcpReportedErrorEventType flags a log entry as an error for Google Cloud Error Reporting.
+const gcpReportedErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// ErrorFormatterOptions configures ErrorFormatterWithOptions.
+type ErrorFormatterOptions struct {
+	// Schema selects the attribute names. Default: ErrorSchemaDefault.
+	Schema ErrorSchema
+	// StacktraceMode selects the stacktrace output. Default: StacktraceModeString.
+	StacktraceMode StacktraceMode
+	// Redactor replaces the PII found in error messages, including the messages
+	// of wrapped causes. Optional. See PIIScannerRedactor.
+	Redactor PIIRedactor
+}
+
 // ErrorFormatter transforms a go error into a readable error.
 //
 // Example:
@@ -24,39 +85,191 @@ import (
 //	  "type": "*io.ErrClosedPipe"
 //	}
 func ErrorFormatter(fieldName string) Formatter {
-	return FormatByFieldType(fieldName, func(err error) slog.Value {
+	return ErrorFormatterWithOptions(fieldName, ErrorFormatterOptions{})
+}
+
+// ErrorFormatterWithOptions transforms a go error into a readable error, with
+// configurable attribute names and stacktrace output.
+//
+// With StacktraceModeFrames, the "stacktrace" attribute holds a []StackFrame,
+// rendered by slog.JSONHandler as:
+//
+//	"stacktrace": [
+//	  {"function": "main.main", "file": "/app/main.go", "line": 42, "package": "main"}
+//	]
+func ErrorFormatterWithOptions(fieldName string, opts ErrorFormatterOptions) Formatter {
+	stacktraceKey := "stacktrace"
+	if opts.Schema == ErrorSchemaECS {
+		stacktraceKey = "stack_trace"
+		if opts.StacktraceMode == StacktraceModeFrames {
+			opts.StacktraceMode = StacktraceModeString
+		}
+	}
+
+	// path of the message, for audits
+	messagePath := "message"
+	if opts.Schema == ErrorSchemaGCP {
+		messagePath = "error_message"
+	}
+	if fieldName != "" {
+		messagePath = fieldName + "." + messagePath
+	}
+
+	format := func(groups []string, err error) slog.Value {
+		message := err.Error()
+		if opts.Redactor != nil {
+			message = opts.Redactor(joinPath(groups, messagePath), message)
+		}
+
+		if opts.Schema == ErrorSchemaGCP {
+			return slog.GroupValue(
+				slog.String("@type", gcpReportedErrorEventType),
+				slog.String("error_message", message),
+				slog.String("error_type", reflect.TypeOf(err).String()),
+				slog.String("stack_trace", goPanicStacktrace()),
+			)
+		}
+
 		values := []slog.Attr{
-			slog.String("message", err.Error()),
+			slog.String("message", message),
 			slog.String("type", reflect.TypeOf(err).String()),
-			slog.String("stacktrace", stacktrace()),
+			slog.Attr{Key: stacktraceKey, Value: stacktraceValue(opts.StacktraceMode)},
 		}
 
 		return slog.GroupValue(values...)
-	})
+	}
+
+	return func(groups []string, attr slog.Attr) (slog.Value, bool) {
+		value := attr.Value
+
+		if value.Kind() == slog.KindGroup || attr.Key != fieldName {
+			return value, false
+		}
+
+		if err, ok := value.Any().(error); ok {
+			return format(groups, err), true
+		}
+
+		return value, false
+	}
 }
 
-func stacktrace() string {
+func stacktraceValue(mode StacktraceMode) slog.Value {
+	switch mode {
+	case StacktraceModeFrames:
+		return slog.AnyValue(stackFrames())
+	case StacktraceModeGoPanic:
+		return slog.StringValue(goPanicStacktrace())
+	default:
+		return slog.StringValue(stacktrace())
+	}
+}
+
+// packagePath is the import path of this package, whose frames are skipped
+// from the top of the stack traces.
+var packagePath = reflect.TypeOf(StackFrame{}).PkgPath()
+
+// callers returns the frames of the current goroutine, skipping log/slog internals.
+// The trace starts at the caller: the frames of this package are skipped until
+// the first frame of another package.
+func callers() []runtime.Frame {
 	var pcs [32]uintptr
 	n := runtime.Callers(1, pcs[:])
 	if n == 0 {
-		return ""
+		return nil
 	}
 	frames := runtime.CallersFrames(pcs[:n])
 
-	var b strings.Builder
+	output := make([]runtime.Frame, 0, n)
 	for {
 		frame, more := frames.Next()
-		if !strings.Contains(frame.Function, "log/slog") {
-			b.WriteString(frame.Function)
-			b.WriteString("\n\t")
-			b.WriteString(frame.File)
-			b.WriteByte(':')
-			b.WriteString(strconv.Itoa(frame.Line))
-			b.WriteByte('\n')
+		isInternal := len(output) == 0 && isLibraryFrame(frame)
+		if !isInternal && !strings.Contains(frame.Function, "log/slog") {
+			output = append(output, frame)
 		}
 		if !more {
 			break
 		}
 	}
+	return output
+}
+
+// isLibraryFrame reports whether a frame belongs to this package. Test files
+// are not part of the library.
+func isLibraryFrame(frame runtime.Frame) bool {
+	return functionPackage(frame.Function) == packagePath && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+func stacktrace() string {
+	var b strings.Builder
+	for _, frame := range callers() {
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func stackFrames() []StackFrame {
+	frames := callers()
+	output := make([]StackFrame, 0, len(frames))
+	for _, frame := range frames {
+		output = append(output, StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+			Package:  functionPackage(frame.Function),
+		})
+	}
+	return output
+}
+
+// goPanicStacktrace mimics the output of runtime.Stack:
+//
+//	goroutine 1 [running]:
+//	main.main()
+//		/app/main.go:42 +0x1d
+func goPanicStacktrace() string {
+	var b strings.Builder
+	b.WriteString("goroutine ")
+	b.WriteString(strconv.FormatUint(goroutineID(), 10))
+	b.WriteString(" [running]:\n")
+	for _, frame := range callers() {
+		b.WriteString(frame.Function)
+		b.WriteString("(...)\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if frame.Entry != 0 {
+			b.WriteString(" +0x")
+			b.WriteString(strconv.FormatUint(uint64(frame.PC-frame.Entry), 16))
+		}
+		b.WriteByte('\n')
+	}
 	return b.String()
 }
+
+// goroutineID parses the header of runtime.Stack ("goroutine 42 [running]:").
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	header := strings.TrimPrefix(string(buf[:n]), "goroutine ")
+	if i := strings.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseUint(header, 10, 64)
+	return id
+}
+
+// functionPackage extracts the import path from a fully qualified function name,
+// such as "github.com/samber/slog-formatter.(*FormatterHandler).Handle".
+func functionPackage(function string) string {
+	lastSlash := strings.LastIndexByte(function, '/')
+	if i := strings.IndexByte(function[lastSlash+1:], '.'); i >= 0 {
+		return function[:lastSlash+1+i]
+	}
+	return function
+}
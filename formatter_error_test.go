@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync/atomic"
 	"testing"
 
@@ -140,6 +141,33 @@ func TestErrorFormatter_WrappedError(t *testing.T) {
 	is.Equal(int32(1), atomic.LoadInt32(&checked))
 }
 
+func TestErrorFormatter_Redactor(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	audit := NewRedactionAudit()
+	redactor := PIIScannerRedactor(PIIScannerOptions{Audit: audit})
+	wrapped := fmt.Errorf("could not notify foobar@example.com: %w", errors.New("invalid card 4111 1111 1111 1111"))
+
+	formatter := ErrorFormatterWithOptions("error", ErrorFormatterOptions{Redactor: redactor})
+	v, ok := formatter([]string{"job"}, slog.Any("error", wrapped))
+	is.True(ok)
+	is.Equal("could not notify *******: invalid card *******", v.Group()[0].Value.String())
+
+	formatter = ErrorFormatterWithOptions("", ErrorFormatterOptions{Schema: ErrorSchemaGCP, Redactor: redactor})
+	v, ok = formatter(nil, slog.Any("", wrapped))
+	is.True(ok)
+	is.Equal("error_message", v.Group()[1].Key)
+	is.Equal("could not notify *******: invalid card *******", v.Group()[1].Value.String())
+
+	is.Equal([]RedactionCount{
+		{Rule: "pii_scanner:credit_card", Path: "error_message", Count: 1},
+		{Rule: "pii_scanner:credit_card", Path: "job.error.message", Count: 1},
+		{Rule: "pii_scanner:email", Path: "error_message", Count: 1},
+		{Rule: "pii_scanner:email", Path: "job.error.message", Count: 1},
+	}, audit.Stats().Counts)
+}
+
 type customError struct {
 	code int
 	msg  string
@@ -273,6 +301,7 @@ func TestStacktrace_ContainsCallerInfo(t *testing.T) {
 
 	logger.Info("test", slog.Any("error", errors.New("test error")))
 	is.Contains(stacktraceValue, "formatter_error_test.go", "stacktrace should contain the test file name")
+	is.NotContains(stacktraceValue, "FormatterHandler", "stacktrace should not start with the frames of the handler")
 }
 
 func TestErrorFormatter_NilError(t *testing.T) {
@@ -303,3 +332,63 @@ func TestErrorFormatter_NilError(t *testing.T) {
 	logger.Info("test", slog.Any("error", nil))
 	is.Equal(int32(1), atomic.LoadInt32(&checked))
 }
+
+func TestErrorFormatterWithOptions_StacktraceFrames(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := ErrorFormatterWithOptions("error", ErrorFormatterOptions{StacktraceMode: StacktraceModeFrames})
+
+	val, ok := formatter(nil, slog.Any("error", errors.New("test error")))
+	is.True(ok)
+
+	var frames []StackFrame
+	for _, a := range val.Group() {
+		if a.Key == "stacktrace" {
+			frames, _ = a.Value.Any().([]StackFrame)
+		}
+	}
+
+	is.NotEmpty(frames)
+	is.Equal("github.com/samber/slog-formatter.TestErrorFormatterWithOptions_StacktraceFrames", frames[0].Function)
+	found := false
+	for _, frame := range frames {
+		is.NotContains(frame.Function, "log/slog")
+		if strings.HasSuffix(frame.File, "formatter_error_test.go") {
+			is.Equal("github.com/samber/slog-formatter", frame.Package)
+			is.Positive(frame.Line)
+			found = true
+		}
+	}
+	is.True(found, "stacktrace should contain the test file name")
+}
+
+func TestErrorFormatterWithOptions_StacktraceGoPanic(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := ErrorFormatterWithOptions("error", ErrorFormatterOptions{StacktraceMode: StacktraceModeGoPanic})
+
+	val, ok := formatter(nil, slog.Any("error", errors.New("test error")))
+	is.True(ok)
+
+	var stacktraceValue string
+	for _, a := range val.Group() {
+		if a.Key == "stacktrace" {
+			stacktraceValue = a.Value.String()
+		}
+	}
+
+	is.Regexp(`^goroutine \d+ \[running\]:\ngithub\.com/samber/slog-formatter\.TestErrorFormatterWithOptions_StacktraceGoPanic\(\.\.\.\)\n`, stacktraceValue)
+	is.Regexp(`\(\.\.\.\)\n\t.+formatter_error_test\.go:\d+ \+0x[0-9a-f]+\n`, stacktraceValue)
+}
+
+func TestFunctionPackage(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal("main", functionPackage("main.main"))
+	is.Equal("github.com/samber/slog-formatter", functionPackage("github.com/samber/slog-formatter.(*FormatterHandler).Handle"))
+	is.Equal("net/http", functionPackage("net/http.(*conn).serve.func1"))
+	is.Equal("runtime", functionPackage("runtime"))
+}
@@ -1,69 +1,398 @@
 package slogformatter
 
 import (
+	"crypto/tls"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 
 	"log/slog"
 )
 
-func headerToAttrs(header http.Header) []any {
-	attrs := make([]any, 0, len(header))
-	for key, values := range header {
-		attrs = append(attrs, slog.String(key, strings.Join(values, ",")))
+// DefaultSensitiveHTTPHeaders lists the headers masked by default by the HTTP formatters.
+var DefaultSensitiveHTTPHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+}
+
+// urlHTTPHeaders lists the headers holding a URL, whose query is redacted like
+// the URL of the request (see URLFormatterOptions).
+var urlHTTPHeaders = map[string]struct{}{
+	"Content-Location": {},
+	"Location":         {},
+	"Referer":          {},
+}
+
+// HTTPHeadersOptions configures how HTTP headers are logged.
+//
+// Header names are canonicalized (see http.CanonicalHeaderKey) before matching,
+// and headers are emitted in alphabetical order. The URLs of the "Referer",
+// "Location" and "Content-Location" headers are redacted with the URL options
+// of the formatter.
+type HTTPHeadersOptions struct {
+	// Hide replaces all headers by "[hidden]".
+	Hide bool
+	// Allowlist keeps only the listed headers. When empty, all headers are kept.
+	Allowlist []string
+	// Denylist drops the listed headers. It takes precedence over Allowlist.
+	Denylist []string
+	// Sensitive headers have their value replaced by "*******".
+	// When nil, DefaultSensitiveHTTPHeaders is used. Use an empty slice to disable masking.
+	Sensitive []string
+	// Audit counts the masked headers under the "http_header" rule, at the
+	// "headers.<name>" path. Optional.
+	Audit *RedactionAudit
+}
+
+type httpHeadersFilter struct {
+	hide      bool
+	allowlist map[string]struct{}
+	denylist  map[string]struct{}
+	sensitive map[string]struct{}
+	audit     *RedactionAudit
+	urls      urlRedactor
+}
+
+func newHTTPHeadersFilter(opts HTTPHeadersOptions, urls urlRedactor) httpHeadersFilter {
+	if opts.Sensitive == nil {
+		opts.Sensitive = DefaultSensitiveHTTPHeaders
+	}
+
+	return httpHeadersFilter{
+		hide:      opts.Hide,
+		allowlist: canonicalHeaderSet(opts.Allowlist),
+		denylist:  canonicalHeaderSet(opts.Denylist),
+		sensitive: canonicalHeaderSet(opts.Sensitive),
+		audit:     opts.Audit,
+		urls:      urls,
+	}
+}
+
+func canonicalHeaderSet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[http.CanonicalHeaderKey(key)] = struct{}{}
+	}
+	return set
+}
+
+// keep reports whether a canonical header name must be logged.
+func (f httpHeadersFilter) keep(key string) bool {
+	if _, ok := f.denylist[key]; ok {
+		return false
+	}
+	if len(f.allowlist) == 0 {
+		return true
+	}
+	_, ok := f.allowlist[key]
+	return ok
+}
+
+func (f httpHeadersFilter) isSensitive(key string) bool {
+	_, ok := f.sensitive[key]
+	return ok
+}
+
+func (f httpHeadersFilter) attr(header http.Header) slog.Attr {
+	if f.hide {
+		return slog.String("headers", "[hidden]")
+	}
+
+	return slog.Group("headers", f.attrs(header)...)
+}
+
+func (f httpHeadersFilter) attrs(header http.Header) []any {
+	keys, values := f.filter(header)
+
+	attrs := make([]any, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.String(key, strings.Join(values[key], ",")))
 	}
 	return attrs
 }
 
+// filter returns the sorted canonical names of the headers to log, and their
+// values. Sensitive values are masked, and URL values are redacted.
+func (f httpHeadersFilter) filter(header http.Header) ([]string, map[string][]string) {
+	// header may contain non-canonical keys when built by hand.
+	canonical := make(map[string][]string, len(header))
+	for key, values := range header {
+		key = http.CanonicalHeaderKey(key)
+		if f.keep(key) {
+			canonical[key] = append(canonical[key], values...)
+		}
+	}
+
+	keys := make([]string, 0, len(canonical))
+	for key := range canonical {
+		keys = append(keys, key)
+		if f.isSensitive(key) {
+			canonical[key] = []string{"*******"}
+			if f.audit != nil {
+				f.audit.add("http_header", "headers."+key)
+			}
+		} else if _, ok := urlHTTPHeaders[key]; ok {
+			values := make([]string, len(canonical[key]))
+			for i, value := range canonical[key] {
+				values[i] = f.urls.redactURLString(value)
+			}
+			canonical[key] = values
+		}
+	}
+	slices.Sort(keys)
+
+	return keys, canonical
+}
+
+// HTTPSchema defines the attribute names emitted by the HTTP formatters.
+type HTTPSchema int
+
+const (
+	// HTTPSchemaDefault emits the historical attributes of this library ("host", "method", "url"...).
+	HTTPSchemaDefault HTTPSchema = iota
+	// HTTPSchemaOTel emits OpenTelemetry semantic conventions ("http.request.method", "url.full"...).
+	// See https://opentelemetry.io/docs/specs/semconv/http/http-spans/.
+	HTTPSchemaOTel
+	// HTTPSchemaECS emits Elastic Common Schema fields ("http.request.method", "url.original"...).
+	// Headers, cookies and route have no ECS field: they are rendered under
+	// ECSCustomNamespace. See https://www.elastic.co/guide/en/ecs/current/ecs-http.html.
+	HTTPSchemaECS
+	// HTTPSchemaGCP emits the fields of the Google Cloud Logging "httpRequest" object
+	// ("requestMethod", "requestUrl", "status"...).
+	// See https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest.
+	HTTPSchemaGCP
+	// HTTPSchemaHAR renders requests and responses as the HARRequest and HARResponse
+	// objects of an HTTP Archive 1.2 entry. See HARCollector.
+	HTTPSchemaHAR
+)
+
+// HTTPRequestFormatterOptions configures HTTPRequestFormatterWithOptions.
+type HTTPRequestFormatterOptions struct {
+	// Schema selects the attribute names. Default: HTTPSchemaDefault.
+	Schema HTTPSchema
+
+	Headers  HTTPHeadersOptions
+	Cookies  HTTPCookiesOptions
+	URL      URLFormatterOptions
+	Body     HTTPBodyOptions
+	ClientIP HTTPClientIPOptions
+
+	// WithProtocol adds "proto", "content_length" and "transfer_encoding".
+	WithProtocol bool
+	// WithTLS adds a "tls" group with the version, cipher suite, server name (SNI)
+	// and negotiated protocol (ALPN) of the connection.
+	WithTLS bool
+	// WithRoute adds the "route" matched by http.ServeMux (http.Request.Pattern, Go >= 1.23).
+	WithRoute bool
+	// WithUserAgent adds a "user_agent" group with the browser, OS and bot flag
+	// parsed from the User-Agent header.
+	WithUserAgent bool
+}
+
+// HTTPResponseFormatterOptions configures HTTPResponseFormatterWithOptions.
+type HTTPResponseFormatterOptions struct {
+	// Schema selects the attribute names. Default: HTTPSchemaDefault.
+	Schema HTTPSchema
+
+	Headers HTTPHeadersOptions
+	Cookies HTTPCookiesOptions
+	// URL configures the redaction of the "Location" and "Content-Location" headers.
+	URL  URLFormatterOptions
+	Body HTTPBodyOptions
+}
+
 // HTTPRequestFormatter transforms a *http.Request into a readable object.
+// Sensitive headers are masked (see DefaultSensitiveHTTPHeaders).
 func HTTPRequestFormatter(ignoreHeaders bool) Formatter {
-	headers := slog.String("headers", "[hidden]")
+	return HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Headers: HTTPHeadersOptions{Hide: ignoreHeaders},
+	})
+}
 
-	return FormatByType(func(req *http.Request) slog.Value {
-		if !ignoreHeaders {
-			headers = slog.Group("headers", headerToAttrs(req.Header)...)
-		}
+// HTTPRequestFormatterWithOptions transforms a *http.Request into a readable object.
+func HTTPRequestFormatterWithOptions(opts HTTPRequestFormatterOptions) Formatter {
+	return FormatByType(newHTTPRequestFormatter(opts).format)
+}
 
-		queryParams := req.URL.Query()
-		queryAttrs := make([]any, 0, len(queryParams))
-		for key, values := range queryParams {
-			queryAttrs = append(queryAttrs, slog.String(key, strings.Join(values, ",")))
-		}
+type httpRequestFormatter struct {
+	opts     HTTPRequestFormatterOptions
+	headers  httpHeadersFilter
+	cookies  httpCookiesFilter
+	urls     urlRedactor
+	body     httpBodyCapturer
+	clientIP httpClientIPResolver
+}
+
+func newHTTPRequestFormatter(opts HTTPRequestFormatterOptions) httpRequestFormatter {
+	return httpRequestFormatter{
+		opts:     opts,
+		headers:  newHTTPHeadersFilter(opts.Headers, newURLRedactor(opts.URL)),
+		cookies:  newHTTPCookiesFilter(opts.Cookies),
+		urls:     newURLRedactor(opts.URL),
+		body:     newHTTPBodyCapturer(opts.Body),
+		clientIP: newHTTPClientIPResolver(opts.ClientIP),
+	}
+}
+
+// format renders a request with the configured schema.
+func (f httpRequestFormatter) format(req *http.Request) slog.Value {
+	switch f.opts.Schema {
+	case HTTPSchemaOTel:
+		return f.otelValue(req)
+	case HTTPSchemaECS:
+		return f.ecsValue(req)
+	case HTTPSchemaGCP:
+		return slog.GroupValue(f.gcpAttrs(req)...)
+	case HTTPSchemaHAR:
+		return slog.AnyValue(f.harRequest(req))
+	default:
+		return f.value(req)
+	}
+}
+
+func (f httpRequestFormatter) value(req *http.Request) slog.Value {
+	u := f.urls.redactURL(req.URL)
+
+	attrs := []slog.Attr{
+		slog.String("host", req.Host),
+		slog.String("method", req.Method),
+		slog.String("url", urlString(u)),
+		{Key: "url", Value: f.urls.urlValue(u)},
+		f.headers.attr(req.Header),
+	}
+
+	if f.cookies.enabled {
+		attrs = append(attrs, f.cookies.requestAttr(req.Cookies()))
+	}
+
+	if f.opts.WithProtocol {
+		attrs = append(
+			attrs,
+			slog.String("proto", req.Proto),
+			slog.Int64("content_length", req.ContentLength),
+			slog.String("transfer_encoding", strings.Join(req.TransferEncoding, ",")),
+		)
+	}
 
-		return slog.GroupValue(
-			slog.String("host", req.Host),
-			slog.String("method", req.Method),
-			slog.String("url", req.URL.String()),
+	if f.opts.WithTLS && req.TLS != nil {
+		attrs = append(
+			attrs,
 			slog.Group(
-				"url",
-				slog.String("url", req.URL.String()),
-				slog.String("scheme", req.URL.Scheme),
-				slog.String("host", req.URL.Host),
-				slog.String("path", req.URL.Path),
-				slog.String("raw_query", req.URL.RawQuery),
-				slog.String("fragment", req.URL.Fragment),
-				slog.Group("query", queryAttrs...),
+				"tls",
+				slog.String("version", tls.VersionName(req.TLS.Version)),
+				slog.String("cipher_suite", tls.CipherSuiteName(req.TLS.CipherSuite)),
+				slog.String("server_name", req.TLS.ServerName),
+				slog.String("negotiated_protocol", req.TLS.NegotiatedProtocol),
 			),
-			headers,
 		)
-	})
+	}
+
+	if f.opts.WithRoute {
+		attrs = append(attrs, slog.String("route", requestRoute(req)))
+	}
+
+	if f.opts.WithUserAgent {
+		attrs = append(attrs, slog.Attr{Key: "user_agent", Value: parseUserAgent(req.UserAgent()).value()})
+	}
+
+	if f.clientIP.enabled {
+		attrs = append(
+			attrs,
+			slog.String("remote_addr", f.clientIP.remoteAddrString(req.RemoteAddr)),
+			slog.String("client_ip", f.clientIP.ipString(f.clientIP.clientIP(req))),
+		)
+	}
+
+	if attr, ok := f.body.attr(&req.Body, req.Header.Get("Content-Type")); ok {
+		attrs = append(attrs, attr)
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// absoluteRequestURL completes the relative URL of server-side requests with the
+// Host header and the scheme. u is modified in place.
+func absoluteRequestURL(req *http.Request, u *url.URL) *url.URL {
+	if u.IsAbs() {
+		return u
+	}
+
+	u.Host = req.Host
+	u.Scheme = "http"
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+	return u
 }
 
 // HTTPResponseFormatter transforms a *http.Response into a readable object.
+// Sensitive headers are masked (see DefaultSensitiveHTTPHeaders).
 func HTTPResponseFormatter(ignoreHeaders bool) Formatter {
-	headers := slog.String("headers", "[hidden]")
+	return HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{
+		Headers: HTTPHeadersOptions{Hide: ignoreHeaders},
+	})
+}
 
-	return FormatByType(func(res *http.Response) slog.Value {
-		if !ignoreHeaders {
-			headers = slog.Group("headers", headerToAttrs(res.Header)...)
-		}
+// HTTPResponseFormatterWithOptions transforms a *http.Response into a readable object.
+func HTTPResponseFormatterWithOptions(opts HTTPResponseFormatterOptions) Formatter {
+	return FormatByType(newHTTPResponseFormatter(opts).format)
+}
 
-		return slog.GroupValue(
-			slog.Int("status", res.StatusCode),
-			slog.String("status_text", res.Status),
-			slog.Int64("content_length", res.ContentLength),
-			slog.Bool("uncompressed", res.Uncompressed),
-			headers,
-		)
-	})
+type httpResponseFormatter struct {
+	opts    HTTPResponseFormatterOptions
+	headers httpHeadersFilter
+	cookies httpCookiesFilter
+	body    httpBodyCapturer
+}
+
+func newHTTPResponseFormatter(opts HTTPResponseFormatterOptions) httpResponseFormatter {
+	return httpResponseFormatter{
+		opts:    opts,
+		headers: newHTTPHeadersFilter(opts.Headers, newURLRedactor(opts.URL)),
+		cookies: newHTTPCookiesFilter(opts.Cookies),
+		body:    newHTTPBodyCapturer(opts.Body),
+	}
+}
+
+// format renders a response with the configured schema.
+func (f httpResponseFormatter) format(res *http.Response) slog.Value {
+	switch f.opts.Schema {
+	case HTTPSchemaOTel:
+		return f.otelValue(res)
+	case HTTPSchemaECS:
+		return f.ecsValue(res)
+	case HTTPSchemaGCP:
+		return slog.GroupValue(f.gcpAttrs(res)...)
+	case HTTPSchemaHAR:
+		return slog.AnyValue(f.harResponse(res))
+	default:
+		return f.value(res)
+	}
+}
+
+func (f httpResponseFormatter) value(res *http.Response) slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("status", res.StatusCode),
+		slog.String("status_text", res.Status),
+		slog.Int64("content_length", res.ContentLength),
+		slog.Bool("uncompressed", res.Uncompressed),
+		f.headers.attr(res.Header),
+	}
+
+	if f.cookies.enabled {
+		attrs = append(attrs, f.cookies.responseAttr(res.Cookies()))
+	}
+
+	if attr, ok := f.body.attr(&res.Body, res.Header.Get("Content-Type")); ok {
+		attrs = append(attrs, attr)
+	}
+
+	return slog.GroupValue(attrs...)
 }
@@ -0,0 +1,339 @@
+package slogformatter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"log/slog"
+)
+
+// DefaultSensitiveBodyFields lists the JSON and form fields masked by default
+// when HTTP bodies are captured. Matching is case-insensitive.
+var DefaultSensitiveBodyFields = []string{
+	"access_token",
+	"api_key",
+	"card_number",
+	"client_secret",
+	"cvv",
+	"password",
+	"refresh_token",
+	"secret",
+	"token",
+}
+
+// HTTPBodyOptions configures the capture of HTTP request and response bodies.
+//
+// The body is read up to MaxSize bytes, then restored so that downstream code
+// can read it again. JSON and form bodies are parsed into groups, text bodies
+// are logged as strings, and binary bodies are logged as size and SHA-256 hash.
+// JSON and form bodies that cannot be parsed, such as truncated JSON bodies, are
+// logged as size and SHA-256 hash too, so that sensitive fields never leak.
+type HTTPBodyOptions struct {
+	// MaxSize is the maximum number of bytes read from the body.
+	// Bodies are captured only when MaxSize is greater than 0.
+	MaxSize int64
+	// SensitiveFields are the JSON and form fields whose value is replaced by "*******".
+	// When nil, DefaultSensitiveBodyFields is used. Use an empty slice to disable masking.
+	SensitiveFields []string
+	// Audit counts the masked fields under the "http_body" rule, at the path of
+	// the field in the body, such as "body.customer.password". Optional.
+	Audit *RedactionAudit
+}
+
+type httpBodyCapturer struct {
+	maxSize   int64
+	sensitive map[string]struct{}
+	audit     *RedactionAudit
+}
+
+func newHTTPBodyCapturer(opts HTTPBodyOptions) httpBodyCapturer {
+	if opts.SensitiveFields == nil {
+		opts.SensitiveFields = DefaultSensitiveBodyFields
+	}
+
+	sensitive := make(map[string]struct{}, len(opts.SensitiveFields))
+	for _, key := range opts.SensitiveFields {
+		sensitive[strings.ToLower(key)] = struct{}{}
+	}
+
+	return httpBodyCapturer{
+		maxSize:   opts.MaxSize,
+		sensitive: sensitive,
+		audit:     opts.Audit,
+	}
+}
+
+func (c httpBodyCapturer) isSensitive(key string) bool {
+	_, ok := c.sensitive[strings.ToLower(key)]
+	return ok
+}
+
+// replayReadCloser replays the bytes consumed by the formatter before reading
+// the rest of the original body.
+type replayReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// capturedBody holds the first bytes of a body.
+type capturedBody struct {
+	contentType string
+	mediaType   string
+	data        []byte
+	truncated   bool
+	err         error
+}
+
+// read reads the body and replaces it with a replay reader. It returns false when
+// body capture is disabled or when there is no body.
+func (c httpBodyCapturer) read(body *io.ReadCloser, contentType string) (capturedBody, bool) {
+	if c.maxSize <= 0 || *body == nil || *body == http.NoBody {
+		return capturedBody{}, false
+	}
+
+	original := *body
+	buf, err := io.ReadAll(io.LimitReader(original, c.maxSize+1))
+	*body = &replayReadCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf), original),
+		Closer: original,
+	}
+
+	truncated := int64(len(buf)) > c.maxSize
+	if truncated {
+		buf = buf[:c.maxSize]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return capturedBody{
+		contentType: contentType,
+		mediaType:   mediaType,
+		data:        buf,
+		truncated:   truncated,
+		err:         err,
+	}, true
+}
+
+// attr reads the body and renders it as a "body" group.
+func (c httpBodyCapturer) attr(body *io.ReadCloser, contentType string) (slog.Attr, bool) {
+	b, ok := c.read(body, contentType)
+	if !ok {
+		return slog.Attr{}, false
+	}
+
+	return slog.Attr{Key: "body", Value: c.value(b)}, true
+}
+
+func (c httpBodyCapturer) value(b capturedBody) slog.Value {
+	if b.err != nil {
+		return slog.GroupValue(slog.String("error", b.err.Error()))
+	}
+
+	attrs := []slog.Attr{
+		slog.String("content_type", b.contentType),
+		slog.Int("size", len(b.data)),
+		slog.Bool("truncated", b.truncated),
+	}
+
+	switch {
+	case !b.truncated && isJSONMediaType(b.mediaType):
+		if content, ok := decodeJSON(b.data); ok {
+			attrs = append(attrs, slog.Attr{Key: "content", Value: c.jsonValue("body", content)})
+			return slog.GroupValue(attrs...)
+		}
+	case b.mediaType == "application/x-www-form-urlencoded":
+		// a truncated form is safe to parse: a cut key has no value
+		if values, err := url.ParseQuery(string(b.data)); err == nil {
+			attrs = append(attrs, slog.Attr{Key: "content", Value: c.formValue(values)})
+			return slog.GroupValue(attrs...)
+		}
+	}
+
+	if c.isRawText(b) {
+		attrs = append(attrs, slog.String("content", string(b.data)))
+	} else {
+		sum := sha256.Sum256(b.data)
+		attrs = append(attrs, slog.String("sha256", hex.EncodeToString(sum[:])))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// text renders the redacted body as a string. It returns false for binary bodies.
+func (c httpBodyCapturer) text(b capturedBody) (string, bool) {
+	if b.err != nil {
+		return "", false
+	}
+
+	switch {
+	case !b.truncated && isJSONMediaType(b.mediaType):
+		if content, ok := decodeJSON(b.data); ok {
+			if output, err := json.Marshal(c.redactJSON("body", content)); err == nil {
+				return string(output), true
+			}
+		}
+	case b.mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(b.data)); err == nil {
+			return c.formText(values), true
+		}
+	}
+
+	if c.isRawText(b) {
+		return string(b.data), true
+	}
+
+	return "", false
+}
+
+// isRawText reports whether the body can be logged as is. JSON and form bodies
+// that could not be redacted are never logged as is, unless masking is disabled.
+func (c httpBodyCapturer) isRawText(b capturedBody) bool {
+	if !isTextMediaType(b.mediaType) || !utf8.Valid(b.data) {
+		return false
+	}
+
+	isStructured := isJSONMediaType(b.mediaType) || b.mediaType == "application/x-www-form-urlencoded"
+	return !isStructured || len(c.sensitive) == 0
+}
+
+func decodeJSON(data []byte) (any, bool) {
+	var content any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&content); err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isTextMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		isJSONMediaType(mediaType) ||
+		mediaType == "application/xml" ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// jsonValue converts a decoded JSON document into a slog.Value. Objects become
+// groups with sorted keys, arrays are kept as []any. The path locates v, for audits.
+func (c httpBodyCapturer) jsonValue(path string, v any) slog.Value {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		attrs := make([]slog.Attr, 0, len(keys))
+		for _, key := range keys {
+			if c.isSensitive(key) {
+				attrs = append(attrs, slog.String(key, "*******"))
+				c.audit.add("http_body", c.fieldPath(path, key))
+			} else {
+				attrs = append(attrs, slog.Attr{Key: key, Value: c.jsonValue(c.fieldPath(path, key), v[key])})
+			}
+		}
+		return slog.GroupValue(attrs...)
+	case []any:
+		return slog.AnyValue(c.redactJSON(path, v))
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return slog.Int64Value(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return slog.Float64Value(f)
+		}
+		return slog.StringValue(v.String())
+	case string:
+		return slog.StringValue(v)
+	case bool:
+		return slog.BoolValue(v)
+	default:
+		return slog.AnyValue(v)
+	}
+}
+
+// fieldPath returns the audited path of a field. Paths are only built when auditing.
+func (c httpBodyCapturer) fieldPath(path string, key string) string {
+	if c.audit == nil {
+		return ""
+	}
+	return path + "." + key
+}
+
+// redactJSON masks sensitive fields of a decoded JSON document, in place. The
+// path locates v, for audits. Array items share the path of the array.
+func (c httpBodyCapturer) redactJSON(path string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if c.isSensitive(key) {
+				v[key] = "*******"
+				c.audit.add("http_body", c.fieldPath(path, key))
+			} else {
+				v[key] = c.redactJSON(c.fieldPath(path, key), item)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = c.redactJSON(path, item)
+		}
+	}
+	return v
+}
+
+// formText encodes form values with sorted keys. Masked values are not escaped.
+func (c httpBodyCapturer) formText(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if c.isSensitive(key) {
+				value = "*******"
+				c.audit.add("http_body", c.fieldPath("body", key))
+			} else {
+				value = url.QueryEscape(value)
+			}
+			pairs = append(pairs, url.QueryEscape(key)+"="+value)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func (c httpBodyCapturer) formValue(values url.Values) slog.Value {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		if c.isSensitive(key) {
+			attrs = append(attrs, slog.String(key, "*******"))
+			c.audit.add("http_body", c.fieldPath("body", key))
+		} else {
+			attrs = append(attrs, slog.String(key, strings.Join(values[key], ",")))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,247 @@
+package slogformatter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bodyAttr(is *assert.Assertions, val slog.Value) map[string]slog.Value {
+	for _, a := range val.Group() {
+		if a.Key == "body" {
+			output := map[string]slog.Value{}
+			for _, b := range a.Value.Group() {
+				output[b.Key] = b.Value
+			}
+			return output
+		}
+	}
+	is.Fail("body attribute not found")
+	return nil
+}
+
+func TestHTTPRequestFormatterWithOptions_JSONBody(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	payload := `{"event":"invoice.paid","amount":42,"ratio":0.5,"customer":{"email":"a@b.c","Password":"secret"},"items":[{"token":"abcd","sku":"x"}]}`
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/webhook", strings.NewReader(payload))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Body: HTTPBodyOptions{MaxSize: 1024},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+
+	body := bodyAttr(is, val)
+	is.Equal("application/json; charset=utf-8", body["content_type"].String())
+	is.Equal(int64(len(payload)), body["size"].Int64())
+	is.False(body["truncated"].Bool())
+	is.Equal(
+		[]slog.Attr{
+			slog.Int64("amount", 42),
+			slog.Group("customer", slog.String("Password", "*******"), slog.String("email", "a@b.c")),
+			slog.String("event", "invoice.paid"),
+			slog.Any("items", []any{map[string]any{"sku": "x", "token": "*******"}}),
+			slog.Float64("ratio", 0.5),
+		},
+		body["content"].Group(),
+	)
+
+	// body can still be read downstream
+	replayed, err := io.ReadAll(req.Body)
+	is.NoError(err)
+	is.Equal(payload, string(replayed))
+	is.NoError(req.Body.Close())
+}
+
+func TestHTTPRequestFormatterWithOptions_FormBody(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/login", strings.NewReader("username=john&password=secret"))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Body: HTTPBodyOptions{MaxSize: 1024},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+	is.Equal(
+		[]slog.Attr{
+			slog.String("password", "*******"),
+			slog.String("username", "john"),
+		},
+		bodyAttr(is, val)["content"].Group(),
+	)
+}
+
+func TestHTTPRequestFormatterWithOptions_TruncatedBody(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	payload := `{"message":"hello world"}`
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/", strings.NewReader(payload))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Body: HTTPBodyOptions{MaxSize: 10},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+
+	body := bodyAttr(is, val)
+	is.True(body["truncated"].Bool())
+	is.Equal(int64(10), body["size"].Int64())
+	_, hasContent := body["content"]
+	is.False(hasContent)
+	is.Len(body["sha256"].String(), 64)
+
+	replayed, err := io.ReadAll(req.Body)
+	is.NoError(err)
+	is.Equal(payload, string(replayed))
+}
+
+func TestHTTPResponseFormatterWithOptions_BinaryBody(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	payload := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": []string{"image/png"}},
+		Body:       io.NopCloser(strings.NewReader(string(payload))),
+	}
+
+	formatter := HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{
+		Body: HTTPBodyOptions{MaxSize: 1024},
+	})
+
+	val, ok := formatter(nil, slog.Any("response", res))
+	is.True(ok)
+
+	sum := sha256.Sum256(payload)
+	body := bodyAttr(is, val)
+	is.Equal(int64(len(payload)), body["size"].Int64())
+	is.Equal(hex.EncodeToString(sum[:]), body["sha256"].String())
+	is.NotContains(body, "content")
+
+	replayed, err := io.ReadAll(res.Body)
+	is.NoError(err)
+	is.Equal(payload, replayed)
+}
+
+func TestHTTPRequestFormatter_BodyDisabledByDefault(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/", strings.NewReader("hello"))
+	is.NoError(err)
+
+	val, ok := HTTPRequestFormatter(false)(nil, slog.Any("request", req))
+	is.True(ok)
+	for _, a := range val.Group() {
+		is.NotEqual("body", a.Key)
+	}
+
+	// no body
+	req, err = http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	is.NoError(err)
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Body: HTTPBodyOptions{MaxSize: 1024},
+	})
+	val, ok = formatter(nil, slog.Any("request", req))
+	is.True(ok)
+	for _, a := range val.Group() {
+		is.NotEqual("body", a.Key)
+	}
+}
+
+func TestHTTPRequestFormatterWithOptions_UnparsableBodyIsNotLogged(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	tests := []struct {
+		name        string
+		contentType string
+		payload     string
+		maxSize     int64
+	}{
+		{"truncated json", "application/json", `{"password":"hunter2","username":"john"}`, int64(len(`{"password":"hunter2","username":"john"}`)) - 1},
+		{"malformed json", "application/json", `{"password":"hunter2",}`, 1024},
+		{"malformed form", "application/x-www-form-urlencoded", "password=hunter2&bad=%zz", 1024},
+	}
+
+	for _, test := range tests {
+		newRequest := func() *http.Request {
+			req, err := http.NewRequest(http.MethodPost, "https://example.com/", strings.NewReader(test.payload))
+			is.NoError(err)
+			req.Header.Set("Content-Type", test.contentType)
+			return req
+		}
+
+		formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+			Body: HTTPBodyOptions{MaxSize: test.maxSize},
+		})
+		val, ok := formatter(nil, slog.Any("request", newRequest()))
+		is.True(ok, test.name)
+		is.NotContains(val.String(), "hunter2", test.name)
+		is.Len(bodyAttr(is, val)["sha256"].String(), 64, test.name)
+
+		for _, schema := range []HTTPSchema{HTTPSchemaECS, HTTPSchemaHAR} {
+			formatter = HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+				Schema: schema,
+				Body:   HTTPBodyOptions{MaxSize: test.maxSize},
+			})
+			val, ok = formatter(nil, slog.Any("request", newRequest()))
+			is.True(ok, test.name)
+			is.NotContains(fmt.Sprint(val.Any()), "hunter2", test.name)
+		}
+
+		curl := HTTPCurlFormatterWithOptions("request", HTTPCurlFormatterOptions{
+			Body: HTTPBodyOptions{MaxSize: test.maxSize},
+		})
+		val, ok = curl(nil, slog.Any("request", newRequest()))
+		is.True(ok, test.name)
+		is.NotContains(val.String(), "hunter2", test.name)
+	}
+}
+
+func TestHTTPRequestFormatterWithOptions_TruncatedFormBody(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	payload := "username=john&password=hunter2"
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/", strings.NewReader(payload))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Body: HTTPBodyOptions{MaxSize: int64(len(payload)) - 2},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+	is.Equal(
+		[]slog.Attr{
+			slog.String("password", "*******"),
+			slog.String("username", "john"),
+		},
+		bodyAttr(is, val)["content"].Group(),
+	)
+}
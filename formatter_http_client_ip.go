@@ -0,0 +1,183 @@
+package slogformatter
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// HTTPClientIPOptions configures the client IP resolution of HTTPRequestFormatterWithOptions.
+//
+// Forwarding headers (RFC 7239 "Forwarded", "X-Forwarded-For" and "X-Real-IP")
+// are trusted only when the direct peer (http.Request.RemoteAddr) is a trusted
+// proxy. The chain is then walked from right to left, and the first address
+// that is not a trusted proxy is the client IP. The walk stops at the first hop
+// that is not an IP address, such as "unknown": no client IP is resolved, since
+// the hops on its left may have been forged by the client.
+type HTTPClientIPOptions struct {
+	// Enabled adds "remote_addr" and "client_ip" attributes to the request.
+	Enabled bool
+	// TrustedProxies lists the IPs or CIDRs of trusted proxies, such as "10.0.0.0/8".
+	// The formatter panics on invalid values.
+	TrustedProxies []string
+	// Anonymize masks the host part of the IPs (/24 for IPv4, /48 for IPv6 by default).
+	Anonymize bool
+	// AnonymizeOptions sets the prefix lengths kept by Anonymize, so that client
+	// IPs are anonymized like by IPAddressFormatterWithOptions. Its Audit is ignored.
+	// The formatter panics when the prefix lengths are out of range.
+	AnonymizeOptions IPAddressFormatterOptions
+}
+
+type httpClientIPResolver struct {
+	enabled        bool
+	trustedProxies []netip.Prefix
+	anonymize      bool
+	anonymizer     ipAnonymizer
+}
+
+func newHTTPClientIPResolver(opts HTTPClientIPOptions) httpClientIPResolver {
+	prefixes := make([]netip.Prefix, 0, len(opts.TrustedProxies))
+	for _, proxy := range opts.TrustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				panic("slog-formatter: invalid trusted proxy: " + proxy)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return httpClientIPResolver{
+		enabled:        opts.Enabled,
+		trustedProxies: prefixes,
+		anonymize:      opts.Anonymize,
+		anonymizer:     newIPAnonymizer(opts.AnonymizeOptions),
+	}
+}
+
+func (r httpClientIPResolver) isTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range r.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the client IP of the request, or an invalid address when
+// it cannot be resolved.
+func (r httpClientIPResolver) clientIP(req *http.Request) netip.Addr {
+	remote, ok := parseIPHost(req.RemoteAddr)
+	if !ok || !r.isTrusted(remote) {
+		return remote
+	}
+
+	if chain := forwardedFor(req.Header.Values("Forwarded")); len(chain) > 0 {
+		return r.walk(chain)
+	}
+	if chain := xForwardedFor(req.Header.Values("X-Forwarded-For")); len(chain) > 0 {
+		return r.walk(chain)
+	}
+	if ip, ok := parseIPHost(strings.TrimSpace(req.Header.Get("X-Real-IP"))); ok {
+		return ip
+	}
+
+	return remote
+}
+
+// walk returns the rightmost untrusted address of a forwarding chain, or the
+// leftmost address when every hop is trusted. Opaque hops are invalid addresses:
+// the walk stops there and returns an invalid address.
+func (r httpClientIPResolver) walk(chain []netip.Addr) netip.Addr {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !chain[i].IsValid() || !r.isTrusted(chain[i]) {
+			return chain[i]
+		}
+	}
+	return chain[0]
+}
+
+func (r httpClientIPResolver) ipString(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return ""
+	}
+	if r.anonymize {
+		addr = anonymizeIP(addr, r.anonymizer.ipv4Bits, r.anonymizer.ipv6Bits)
+	}
+	return addr.Unmap().String()
+}
+
+// remoteAddrString renders http.Request.RemoteAddr, anonymized when requested.
+func (r httpClientIPResolver) remoteAddrString(remoteAddr string) string {
+	if !r.anonymize {
+		return remoteAddr
+	}
+
+	host, port, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host, port = remoteAddr, ""
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return "*******"
+	}
+
+	host = r.ipString(addr)
+	if port == "" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// parseIPHost parses "ip", "ip:port", "[ipv6]" and "[ipv6]:port".
+func parseIPHost(value string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// xForwardedFor parses "X-Forwarded-For: client, proxy1, proxy2" headers.
+// Hops that are not IP addresses are kept as invalid addresses.
+func xForwardedFor(values []string) []netip.Addr {
+	chain := []netip.Addr{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			ip, _ := parseIPHost(strings.TrimSpace(item))
+			chain = append(chain, ip)
+		}
+	}
+	return chain
+}
+
+// forwardedFor parses the "for" parameters of RFC 7239 "Forwarded" headers, such as
+// `Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"`.
+// Obfuscated identifiers ("unknown", "_hidden") are kept as invalid addresses.
+func forwardedFor(values []string) []netip.Addr {
+	chain := []netip.Addr{}
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(key, "for") {
+					continue
+				}
+
+				ip, _ := parseIPHost(strings.Trim(val, `"`))
+				chain = append(chain, ip)
+			}
+		}
+	}
+	return chain
+}
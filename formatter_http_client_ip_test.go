@@ -0,0 +1,136 @@
+package slogformatter
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClientIPResolver(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	resolver := newHTTPClientIPResolver(HTTPClientIPOptions{
+		Enabled:        true,
+		TrustedProxies: []string{"10.0.0.0/8", "2001:db8::/32", "192.168.1.1"},
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		{"direct", "203.0.113.7:1234", nil, "203.0.113.7"},
+		{"untrusted peer ignores headers", "203.0.113.7:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}, "203.0.113.7"},
+		{"x-forwarded-for", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1, 10.0.0.2"}}, "1.1.1.1"},
+		{"x-forwarded-for spoofed", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"6.6.6.6, 1.1.1.1, 10.0.0.2"}}, "1.1.1.1"},
+		{"x-forwarded-for multiple headers", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1", "2.2.2.2"}}, "2.2.2.2"},
+		{"x-forwarded-for all trusted", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"x-real-ip", "192.168.1.1:1234", http.Header{"X-Real-Ip": {"1.1.1.1"}}, "1.1.1.1"},
+		{"forwarded", "10.0.0.1:1234", http.Header{"Forwarded": {`for=192.0.2.60;proto=http;by=203.0.113.43, For="[2001:db8:cafe::17]:4711"`}}, "192.0.2.60"},
+		{"forwarded ipv6", "[2001:db8::1]:1234", http.Header{"Forwarded": {`for="[2a00:1450::17]:4711"`}}, "2a00:1450::17"},
+		{"forwarded takes precedence", "10.0.0.1:1234", http.Header{"Forwarded": {"for=1.1.1.1"}, "X-Forwarded-For": {"2.2.2.2"}}, "1.1.1.1"},
+		{"forwarded obfuscated", "10.0.0.1:1234", http.Header{"Forwarded": {"for=_hidden, for=unknown"}, "X-Forwarded-For": {"2.2.2.2"}}, ""},
+		{"forwarded spoofed before unknown", "10.0.0.1:1234", http.Header{"Forwarded": {"for=6.6.6.6, for=unknown"}}, ""},
+		{"forwarded obfuscated trusted hop", "10.0.0.1:1234", http.Header{"Forwarded": {"for=_hidden, for=1.1.1.1, for=10.0.0.2"}}, "1.1.1.1"},
+		{"x-forwarded-for spoofed before unknown", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"6.6.6.6, unknown"}}, ""},
+		{"x-forwarded-for spoofed before unknown and trusted hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"6.6.6.6, unknown, 10.0.0.2"}}, ""},
+		{"x-forwarded-for empty hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"6.6.6.6, "}}, ""},
+		{"ipv4-mapped", "[::ffff:10.0.0.1]:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}, "1.1.1.1"},
+		{"invalid remote addr", "pipe", nil, ""},
+	}
+
+	for _, tt := range tests {
+		req := &http.Request{RemoteAddr: tt.remoteAddr, Header: tt.header}
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		is.Equal(tt.expected, resolver.ipString(resolver.clientIP(req)), tt.name)
+	}
+}
+
+func TestHTTPClientIPResolver_InvalidTrustedProxy(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.PanicsWithValue("slog-formatter: invalid trusted proxy: foobar", func() {
+		newHTTPClientIPResolver(HTTPClientIPOptions{TrustedProxies: []string{"foobar"}})
+	})
+}
+
+func TestHTTPRequestFormatterWithOptions_ClientIP(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	is.NoError(err)
+	req.RemoteAddr = "10.1.2.3:4567"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		ClientIP: HTTPClientIPOptions{
+			Enabled:        true,
+			TrustedProxies: []string{"10.0.0.0/8"},
+			Anonymize:      true,
+		},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+
+	fields := map[string]string{}
+	for _, a := range val.Group() {
+		fields[a.Key] = a.Value.String()
+	}
+	is.Equal("10.1.2.0:4567", fields["remote_addr"])
+	is.Equal("203.0.113.0", fields["client_ip"])
+
+	// disabled by default
+	val, ok = HTTPRequestFormatter(true)(nil, slog.Any("request", req))
+	is.True(ok)
+	for _, a := range val.Group() {
+		is.NotEqual("client_ip", a.Key)
+		is.NotEqual("remote_addr", a.Key)
+	}
+}
+
+func TestHTTPRequestFormatterWithOptions_ClientIPAnonymizeOptions(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	is.NoError(err)
+	req.RemoteAddr = "203.0.113.7:4567"
+
+	opts := IPAddressFormatterOptions{IPv4PrefixLength: 16}
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		ClientIP: HTTPClientIPOptions{
+			Enabled:          true,
+			Anonymize:        true,
+			AnonymizeOptions: opts,
+		},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+
+	fields := map[string]string{}
+	for _, a := range val.Group() {
+		fields[a.Key] = a.Value.String()
+	}
+	is.Equal("203.0.0.0:4567", fields["remote_addr"])
+	is.Equal("203.0.0.0", fields["client_ip"])
+
+	// same output as IPAddressFormatterWithOptions
+	expected, _ := IPAddressFormatterWithOptions("ip", opts)(nil, slog.String("ip", "203.0.113.7"))
+	is.Equal(expected.String(), fields["client_ip"])
+
+	is.PanicsWithValue("slog-formatter: IPv4 prefix length must be between 1 and 32", func() {
+		HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+			ClientIP: HTTPClientIPOptions{AnonymizeOptions: IPAddressFormatterOptions{IPv4PrefixLength: 33}},
+		})
+	})
+}
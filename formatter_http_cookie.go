@@ -0,0 +1,185 @@
+package slogformatter
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"log/slog"
+)
+
+// DefaultSensitiveCookiePatterns lists the cookie name patterns masked by default.
+// See HTTPCookiesOptions.
+var DefaultSensitiveCookiePatterns = []string{
+	"*session*",
+	"*sess*",
+	"sid",
+	"*.sid",
+	"*_sid",
+	"*token*",
+	"*auth*",
+	"*jwt*",
+	"*csrf*",
+	"*xsrf*",
+	"remember_*",
+}
+
+// HTTPCookiesOptions configures how cookies are logged.
+type HTTPCookiesOptions struct {
+	// Enabled adds a "cookies" group, parsed from the "Cookie" request header
+	// or the "Set-Cookie" response headers.
+	Enabled bool
+	// SensitivePatterns are cookie name patterns whose value is replaced by "*******".
+	// Patterns use the path.Match syntax and are case-insensitive.
+	// When nil, DefaultSensitiveCookiePatterns is used. Use an empty slice to disable masking.
+	// The formatter panics on invalid patterns.
+	SensitivePatterns []string
+	// Audit counts the masked cookies under the "cookie" rule, at the
+	// "cookies.<name>" path. Optional.
+	Audit *RedactionAudit
+}
+
+type httpCookiesFilter struct {
+	enabled  bool
+	patterns []string
+	audit    *RedactionAudit
+}
+
+func newHTTPCookiesFilter(opts HTTPCookiesOptions) httpCookiesFilter {
+	if opts.SensitivePatterns == nil {
+		opts.SensitivePatterns = DefaultSensitiveCookiePatterns
+	}
+
+	patterns := make([]string, 0, len(opts.SensitivePatterns))
+	for _, pattern := range opts.SensitivePatterns {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic("slog-formatter: invalid cookie pattern: " + pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return httpCookiesFilter{
+		enabled:  opts.Enabled,
+		patterns: patterns,
+		audit:    opts.Audit,
+	}
+}
+
+func (f httpCookiesFilter) isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range f.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f httpCookiesFilter) cookieValue(cookie *http.Cookie) string {
+	if f.isSensitive(cookie.Name) {
+		if f.audit != nil {
+			f.audit.add("cookie", "cookies."+cookie.Name)
+		}
+		return "*******"
+	}
+	return cookie.Value
+}
+
+// requestAttr renders "Cookie" request headers: name -> value.
+func (f httpCookiesFilter) requestAttr(cookies []*http.Cookie) slog.Attr {
+	attrs := make([]slog.Attr, 0, len(cookies))
+	for _, cookie := range cookies {
+		attrs = append(attrs, slog.String(cookie.Name, f.cookieValue(cookie)))
+	}
+	return slog.Attr{Key: "cookies", Value: slog.GroupValue(attrs...)}
+}
+
+// responseAttr renders "Set-Cookie" response headers: name -> value and attributes.
+func (f httpCookiesFilter) responseAttr(cookies []*http.Cookie) slog.Attr {
+	attrs := make([]slog.Attr, 0, len(cookies))
+	for _, cookie := range cookies {
+		attrs = append(attrs, slog.Attr{Key: cookie.Name, Value: f.setCookieValue(cookie)})
+	}
+	return slog.Attr{Key: "cookies", Value: slog.GroupValue(attrs...)}
+}
+
+func (f httpCookiesFilter) setCookieValue(cookie *http.Cookie) slog.Value {
+	attrs := []slog.Attr{
+		slog.String("value", f.cookieValue(cookie)),
+		slog.String("path", cookie.Path),
+		slog.String("domain", cookie.Domain),
+	}
+
+	if !cookie.Expires.IsZero() {
+		attrs = append(attrs, slog.Time("expires", cookie.Expires))
+	}
+	if cookie.MaxAge != 0 {
+		attrs = append(attrs, slog.Int("max_age", cookie.MaxAge))
+	}
+
+	attrs = append(
+		attrs,
+		slog.Bool("secure", cookie.Secure),
+		slog.Bool("http_only", cookie.HttpOnly),
+		slog.String("same_site", sameSiteString(cookie.SameSite)),
+	)
+
+	return slog.GroupValue(attrs...)
+}
+
+func sameSiteString(sameSite http.SameSite) string {
+	switch sameSite {
+	case http.SameSiteDefaultMode:
+		return "Default"
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
+
+// HTTPCookieFormatter transforms a *http.Cookie or []*http.Cookie into a readable object.
+// Values of session cookies are masked (see DefaultSensitiveCookiePatterns).
+func HTTPCookieFormatter() Formatter {
+	return HTTPCookieFormatterWithOptions(HTTPCookiesOptions{})
+}
+
+// HTTPCookieFormatterWithOptions transforms a *http.Cookie or []*http.Cookie into a readable object.
+// HTTPCookiesOptions.Enabled is ignored.
+//
+// Example:
+//
+//	"cookie": &http.Cookie{Name: "session_id", Value: "abcd", Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode}
+//
+// will be transformed into:
+//
+//	"cookie": {
+//	  "value": "*******",
+//	  "path": "",
+//	  "domain": "",
+//	  "secure": true,
+//	  "http_only": true,
+//	  "same_site": "Lax"
+//	}
+func HTTPCookieFormatterWithOptions(opts HTTPCookiesOptions) Formatter {
+	filter := newHTTPCookiesFilter(opts)
+
+	formatCookie := FormatByType(func(cookie *http.Cookie) slog.Value {
+		return filter.setCookieValue(cookie)
+	})
+	formatCookies := FormatByType(func(cookies []*http.Cookie) slog.Value {
+		return filter.responseAttr(cookies).Value
+	})
+
+	return func(groups []string, attr slog.Attr) (slog.Value, bool) {
+		v, okCookie := formatCookie(groups, attr)
+		attr.Value = v
+		v, okCookies := formatCookies(groups, attr)
+		return v, okCookie || okCookies
+	}
+}
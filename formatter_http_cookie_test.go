@@ -0,0 +1,132 @@
+package slogformatter
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRequestFormatterWithOptions_Cookies(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	is.NoError(err)
+	req.Header.Set("Cookie", "theme=dark; SESSIONID=abcd; connect.sid=efgh; lang=fr")
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Cookies: HTTPCookiesOptions{Enabled: true},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+
+	found := false
+	for _, a := range val.Group() {
+		switch a.Key {
+		case "cookies":
+			is.Equal(
+				[]slog.Attr{
+					slog.String("theme", "dark"),
+					slog.String("SESSIONID", "*******"),
+					slog.String("connect.sid", "*******"),
+					slog.String("lang", "fr"),
+				},
+				a.Value.Group(),
+			)
+			found = true
+		case "headers":
+			// raw header is still masked
+			is.Equal([]slog.Attr{slog.String("Cookie", "*******")}, a.Value.Group())
+		}
+	}
+	is.True(found)
+}
+
+func TestHTTPResponseFormatterWithOptions_SetCookies(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Set-Cookie": []string{
+				"session_id=abcd; Path=/; Domain=example.com; Expires=Wed, 21 Oct 2026 07:28:00 GMT; Secure; HttpOnly; SameSite=Strict",
+				"theme=dark; Max-Age=3600",
+			},
+		},
+	}
+
+	formatter := HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{
+		Cookies: HTTPCookiesOptions{
+			Enabled:           true,
+			SensitivePatterns: []string{"SESSION_*"},
+		},
+	})
+
+	val, ok := formatter(nil, slog.Any("response", res))
+	is.True(ok)
+
+	found := false
+	for _, a := range val.Group() {
+		if a.Key == "cookies" {
+			is.Equal(
+				[]slog.Attr{
+					slog.Group("session_id",
+						slog.String("value", "*******"),
+						slog.String("path", "/"),
+						slog.String("domain", "example.com"),
+						slog.Time("expires", time.Date(2026, 10, 21, 7, 28, 0, 0, time.UTC)),
+						slog.Bool("secure", true),
+						slog.Bool("http_only", true),
+						slog.String("same_site", "Strict"),
+					),
+					slog.Group("theme",
+						slog.String("value", "dark"),
+						slog.String("path", ""),
+						slog.String("domain", ""),
+						slog.Int("max_age", 3600),
+						slog.Bool("secure", false),
+						slog.Bool("http_only", false),
+						slog.String("same_site", ""),
+					),
+				},
+				a.Value.Group(),
+			)
+			found = true
+		}
+	}
+	is.True(found)
+}
+
+func TestHTTPCookieFormatter(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := HTTPCookieFormatter()
+
+	val, ok := formatter(nil, slog.Any("cookie", &http.Cookie{Name: "auth_token", Value: "abcd", SameSite: http.SameSiteLaxMode}))
+	is.True(ok)
+	is.Equal("*******", val.Group()[0].Value.String())
+	is.Equal(slog.String("same_site", "Lax"), val.Group()[len(val.Group())-1])
+
+	val, ok = formatter(nil, slog.Any("cookies", []*http.Cookie{{Name: "theme", Value: "dark"}}))
+	is.True(ok)
+	is.Equal("theme", val.Group()[0].Key)
+	is.Equal("dark", val.Group()[0].Value.Group()[0].Value.String())
+
+	_, ok = formatter(nil, slog.String("cookie", "session=abcd"))
+	is.False(ok)
+}
+
+func TestHTTPCookieFormatterWithOptions_InvalidPattern(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Panics(func() {
+		HTTPCookieFormatterWithOptions(HTTPCookiesOptions{SensitivePatterns: []string{"[session"}})
+	})
+}
@@ -0,0 +1,193 @@
+package slogformatter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"log/slog"
+)
+
+// HTTPCurlFormatterOptions configures HTTPCurlFormatterWithOptions.
+type HTTPCurlFormatterOptions struct {
+	// Headers selects the headers of the command. Values of sensitive headers are
+	// replaced by an environment variable placeholder, such as "$AUTHORIZATION".
+	Headers HTTPHeadersOptions
+	// URL configures the redaction of the URL.
+	URL URLFormatterOptions
+	// Body configures the capture of the body, sent with --data-raw.
+	// Binary bodies are omitted.
+	Body HTTPBodyOptions
+	// MaxLength is the maximum length of the command, in bytes. Longer commands
+	// are truncated and end with "...". Default: 8192.
+	MaxLength int
+}
+
+// HTTPCurlFormatter renders a *http.Request as a curl command, reproducing the
+// request. Bodies up to 4KB are included.
+//
+// Example:
+//
+//	logger.Error("request failed", slog.Any("curl", req))
+//
+// passed to HTTPCurlFormatter("curl"), will be transformed into:
+//
+//	"curl": "curl -X POST 'https://api.example.com/v1/users?token=*******' -H 'Authorization: '\"$AUTHORIZATION\" -H 'Content-Type: application/json' --data-raw '{\"name\":\"John\"}'"
+func HTTPCurlFormatter(fieldName string) Formatter {
+	return HTTPCurlFormatterWithOptions(fieldName, HTTPCurlFormatterOptions{
+		Body: HTTPBodyOptions{MaxSize: 4096},
+	})
+}
+
+// HTTPCurlFormatterWithOptions renders a *http.Request as a curl command.
+// Arguments are quoted for POSIX shells. Strings holding control characters or
+// invalid UTF-8 use the $'...' quoting of bash and zsh.
+func HTTPCurlFormatterWithOptions(fieldName string, opts HTTPCurlFormatterOptions) Formatter {
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = 8192
+	}
+
+	urls := newURLRedactor(opts.URL)
+	headers := newHTTPHeadersFilter(opts.Headers, urls)
+	body := newHTTPBodyCapturer(opts.Body)
+
+	return FormatByFieldType(fieldName, func(req *http.Request) slog.Value {
+		u := absoluteRequestURL(req, urls.redactURL(req.URL))
+
+		args := []string{"curl"}
+		if req.Method != "" && req.Method != http.MethodGet {
+			args = append(args, "-X", shellQuote(req.Method))
+		}
+		args = append(args, shellQuote(urlString(u)))
+
+		if req.Host != "" && req.Host != u.Host {
+			args = append(args, "-H", shellQuote("Host: "+req.Host))
+		}
+
+		if !headers.hide {
+			keys, values := headers.filter(req.Header)
+			for _, key := range keys {
+				if headers.isSensitive(key) {
+					args = append(args, "-H", shellQuote(key+": ")+`"$`+curlPlaceholder(key)+`"`)
+					continue
+				}
+				for _, value := range values[key] {
+					args = append(args, "-H", shellQuote(key+": "+value))
+				}
+			}
+		}
+
+		if b, ok := body.read(&req.Body, req.Header.Get("Content-Type")); ok {
+			if text, ok := body.text(b); ok {
+				args = append(args, "--data-raw", shellQuote(text))
+			}
+		}
+
+		return slog.StringValue(truncateUTF8(strings.Join(args, " "), opts.MaxLength))
+	})
+}
+
+// curlPlaceholder converts a header name into an environment variable name:
+// "X-Api-Key" becomes "X_API_KEY".
+func curlPlaceholder(header string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, header)
+}
+
+// shellQuote quotes a string for POSIX shells. Safe strings are left unquoted.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+
+	safe := true
+	ansi := !utf8.ValidString(s)
+	for _, r := range s {
+		if !isShellSafe(r) {
+			safe = false
+		}
+		if r != utf8.RuneError && !unicode.IsPrint(r) && r != ' ' {
+			ansi = true
+		}
+	}
+
+	switch {
+	case safe:
+		return s
+	case ansi:
+		return ansiCQuote(s)
+	default:
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+}
+
+func isShellSafe(r rune) bool {
+	return (r >= 'a' && r <= 'z') ||
+		(r >= 'A' && r <= 'Z') ||
+		(r >= '0' && r <= '9') ||
+		strings.ContainsRune("_@%+=:,./-", r)
+}
+
+// ansiCQuote quotes a string with the $'...' syntax, escaping control
+// characters and invalid bytes. Printable unicode characters are kept as is.
+func ansiCQuote(s string) string {
+	var b strings.Builder
+	b.WriteString("$'")
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			b.WriteString(`\x`)
+			b.WriteString(strconv.FormatUint(uint64(s[i]), 16))
+		case r == '\'' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == ' ' || unicode.IsPrint(r):
+			b.WriteRune(r)
+		case r < 0x10000:
+			b.WriteString(`\u`)
+			b.WriteString(leftPad(strconv.FormatUint(uint64(r), 16), 4))
+		default:
+			b.WriteString(`\U`)
+			b.WriteString(leftPad(strconv.FormatUint(uint64(r), 16), 8))
+		}
+		i += size
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+func leftPad(s string, length int) string {
+	if len(s) >= length {
+		return s
+	}
+	return strings.Repeat("0", length-len(s)) + s
+}
+
+// truncateUTF8 cuts s to at most maxLength bytes, without splitting a rune.
+func truncateUTF8(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+
+	cut := max(maxLength-3, 0)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
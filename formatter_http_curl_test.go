@@ -0,0 +1,95 @@
+package slogformatter
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPCurlFormatter(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/users?q=it's&token=abcd", strings.NewReader(`{"name":"O'Brien","password":"secret"}`))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abcd")
+	req.Header.Set("X-Api-Key", "abcd")
+
+	formatter := HTTPCurlFormatter("curl")
+
+	_, ok := formatter(nil, slog.Any("request", req))
+	is.False(ok)
+
+	v, ok := formatter(nil, slog.Any("curl", req))
+	is.True(ok)
+	is.Equal(
+		`curl -X POST 'https://api.example.com/v1/users?q=it'\''s&token=*******'`+
+			` -H 'Authorization: '"$AUTHORIZATION"`+
+			` -H 'Content-Type: application/json'`+
+			` -H 'X-Api-Key: '"$X_API_KEY"`+
+			` --data-raw '{"name":"O'\''Brien","password":"*******"}'`,
+		v.String(),
+	)
+
+	body, err := io.ReadAll(req.Body)
+	is.NoError(err)
+	is.Equal(`{"name":"O'Brien","password":"secret"}`, string(body))
+}
+
+func TestHTTPCurlFormatterWithOptions(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=caf%C3%A9", nil)
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("Accept-Language", "fr")
+
+	formatter := HTTPCurlFormatterWithOptions("curl", HTTPCurlFormatterOptions{
+		Headers: HTTPHeadersOptions{Allowlist: []string{"Accept"}},
+	})
+
+	v, ok := formatter(nil, slog.Any("curl", req))
+	is.True(ok)
+	is.Equal(`curl 'http://example.com/search?q=caf%C3%A9' -H 'Accept: */*'`, v.String())
+
+	formatter = HTTPCurlFormatterWithOptions("curl", HTTPCurlFormatterOptions{
+		Headers:   HTTPHeadersOptions{Hide: true},
+		MaxLength: 20,
+	})
+
+	v, ok = formatter(nil, slog.Any("curl", req))
+	is.True(ok)
+	is.Equal(`curl 'http://exam...`, v.String())
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal(`''`, shellQuote(""))
+	is.Equal(`https://example.com/a-b_c`, shellQuote("https://example.com/a-b_c"))
+	is.Equal(`'hello world'`, shellQuote("hello world"))
+	is.Equal(`'$HOME `+"`id`"+` "x"'`, shellQuote("$HOME `id` \"x\""))
+	is.Equal(`'it'\''s'`, shellQuote("it's"))
+	is.Equal(`'Zoë 日本語 🚀'`, shellQuote("Zoë 日本語 🚀"))
+	is.Equal(`$'line 1\nline\t2 it\'s'`, shellQuote("line 1\nline\t2 it's"))
+	is.Equal(`$'bell\u0007 zero\u200b'`, shellQuote("bell\a zero\u200b"))
+	is.Equal(`$'invalid \xff'`, shellQuote("invalid \xff"))
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal("hello", truncateUTF8("hello", 5))
+	is.Equal("he...", truncateUTF8("hello world", 5))
+	is.Equal("日...", truncateUTF8("日本語", 7))
+	is.Equal("...", truncateUTF8("日本語", 4))
+	is.Equal("ab...", truncateUTF8("ab日本語", 6))
+}
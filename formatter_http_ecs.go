@@ -0,0 +1,176 @@
+package slogformatter
+
+import (
+	"crypto/tls"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"log/slog"
+)
+
+// ECSCustomNamespace prefixes the fields rendered by HTTPSchemaECS that have no
+// Elastic Common Schema equivalent: headers, cookies and route pattern, such as
+// "custom.http.request.headers". ECS reserves its own namespaces, so these fields
+// must not be added under "http.*" or "url.*".
+const ECSCustomNamespace = "custom"
+
+// ecsValue renders a request with Elastic Common Schema fields. Attribute keys
+// are flat and dot-separated, such as "http.request.method", and field types
+// follow the ECS mappings (eg: "url.port" is a number, "http.request.body.content"
+// is a string).
+func (f httpRequestFormatter) ecsValue(req *http.Request) slog.Value {
+	u := f.urls.redactURL(req.URL)
+
+	attrs := []slog.Attr{
+		slog.String("http.request.method", req.Method),
+		slog.String("url.original", urlString(u)),
+		slog.String("url.scheme", u.Scheme),
+		slog.String("url.path", u.Path),
+		slog.String("url.query", u.RawQuery),
+		slog.String("url.fragment", u.Fragment),
+	}
+
+	host := req.Host
+	if host == "" {
+		host = u.Host
+	}
+	attrs = append(attrs, ecsDomainAttrs(host)...)
+
+	if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil {
+		attrs = append(attrs, slog.String("http.request.mime_type", mediaType))
+	}
+	attrs = append(attrs, slog.String("user_agent.original", req.UserAgent()))
+
+	if !f.headers.hide {
+		attrs = append(attrs, slog.Group(ECSCustomNamespace+".http.request.headers", f.headers.attrs(req.Header)...))
+	}
+
+	if f.cookies.enabled {
+		attr := f.cookies.requestAttr(req.Cookies())
+		attr.Key = ECSCustomNamespace + ".http.request.cookies"
+		attrs = append(attrs, attr)
+	}
+
+	if f.opts.WithProtocol {
+		attrs = append(attrs, slog.String("http.version", strings.TrimPrefix(req.Proto, "HTTP/")))
+		if req.ContentLength >= 0 {
+			attrs = append(attrs, slog.Int64("http.request.body.bytes", req.ContentLength))
+		}
+	}
+
+	if f.opts.WithTLS && req.TLS != nil {
+		name, version := tlsProtocol(req.TLS.Version)
+		attrs = append(
+			attrs,
+			slog.String("tls.version_protocol", name),
+			slog.String("tls.version", version),
+			slog.String("tls.cipher", tls.CipherSuiteName(req.TLS.CipherSuite)),
+			slog.String("tls.client.server_name", req.TLS.ServerName),
+			slog.String("tls.next_protocol", req.TLS.NegotiatedProtocol),
+		)
+	}
+
+	if f.opts.WithRoute {
+		attrs = append(attrs, slog.String(ECSCustomNamespace+".http.route", requestRoute(req)))
+	}
+
+	if f.opts.WithUserAgent {
+		ua := parseUserAgent(req.UserAgent())
+		attrs = append(
+			attrs,
+			slog.String("user_agent.name", ua.browser),
+			slog.String("user_agent.version", ua.browserVersion),
+			slog.String("user_agent.os.name", ua.os),
+		)
+	}
+
+	if f.clientIP.enabled {
+		if ip := f.clientIP.ipString(f.clientIP.clientIP(req)); ip != "" {
+			attrs = append(attrs, slog.String("client.ip", ip))
+		}
+
+		// source.ip is typed as "ip": unparsable addresses would be rejected.
+		peer := f.clientIP.remoteAddrString(req.RemoteAddr)
+		if peerHost, peerPort, err := net.SplitHostPort(peer); err == nil {
+			if _, ok := parseIPHost(peerHost); ok {
+				attrs = append(attrs, slog.String("source.ip", peerHost))
+			}
+			if port, err := strconv.Atoi(peerPort); err == nil {
+				attrs = append(attrs, slog.Int("source.port", port))
+			}
+		}
+	}
+
+	if b, ok := f.body.read(&req.Body, req.Header.Get("Content-Type")); ok {
+		attrs = append(attrs, ecsBodyAttrs("http.request.body", f.body, b, req.ContentLength)...)
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// ecsValue renders a response with Elastic Common Schema fields.
+func (f httpResponseFormatter) ecsValue(res *http.Response) slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("http.response.status_code", res.StatusCode),
+	}
+
+	if res.ContentLength >= 0 {
+		attrs = append(attrs, slog.Int64("http.response.body.bytes", res.ContentLength))
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil {
+		attrs = append(attrs, slog.String("http.response.mime_type", mediaType))
+	}
+
+	if !f.headers.hide {
+		attrs = append(attrs, slog.Group(ECSCustomNamespace+".http.response.headers", f.headers.attrs(res.Header)...))
+	}
+
+	if f.cookies.enabled {
+		attr := f.cookies.responseAttr(res.Cookies())
+		attr.Key = ECSCustomNamespace + ".http.response.cookies"
+		attrs = append(attrs, attr)
+	}
+
+	if b, ok := f.body.read(&res.Body, res.Header.Get("Content-Type")); ok {
+		attrs = append(attrs, ecsBodyAttrs("http.response.body", f.body, b, res.ContentLength)...)
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func ecsDomainAttrs(host string) []slog.Attr {
+	if host == "" {
+		return nil
+	}
+
+	hostname, rawPort, err := net.SplitHostPort(host)
+	if err != nil {
+		return []slog.Attr{slog.String("url.domain", strings.Trim(host, "[]"))}
+	}
+
+	attrs := []slog.Attr{slog.String("url.domain", hostname)}
+	if port, err := strconv.Atoi(rawPort); err == nil {
+		attrs = append(attrs, slog.Int("url.port", port))
+	}
+	return attrs
+}
+
+// ecsBodyAttrs renders "<prefix>.content" as a string, since ECS maps it as a
+// text field. "<prefix>.bytes" is the captured size when the content length is unknown.
+func ecsBodyAttrs(prefix string, capturer httpBodyCapturer, b capturedBody, contentLength int64) []slog.Attr {
+	attrs := []slog.Attr{}
+
+	if contentLength < 0 && !b.truncated && b.err == nil {
+		attrs = append(attrs, slog.Int(prefix+".bytes", len(b.data)))
+	}
+
+	if content, ok := capturer.text(b); ok {
+		attrs = append(attrs, slog.String(prefix+".content", content))
+	}
+
+	return attrs
+}
@@ -0,0 +1,54 @@
+package slogformatter
+
+import (
+	"net/http"
+	"strconv"
+
+	"log/slog"
+)
+
+// gcpAttrs renders the request fields of a Cloud Logging "httpRequest" object.
+// Sizes are strings, as int64 values in the proto3 JSON mapping.
+func (f httpRequestFormatter) gcpAttrs(req *http.Request) []slog.Attr {
+	u := f.urls.redactURL(req.URL)
+
+	attrs := []slog.Attr{
+		slog.String("requestMethod", req.Method),
+		slog.String("requestUrl", urlString(u)),
+	}
+
+	if req.ContentLength >= 0 {
+		attrs = append(attrs, slog.String("requestSize", strconv.FormatInt(req.ContentLength, 10)))
+	}
+
+	attrs = append(attrs, slog.String("userAgent", req.UserAgent()))
+
+	if referer := req.Referer(); referer != "" {
+		attrs = append(attrs, slog.String("referer", f.urls.redactURLString(referer)))
+	}
+
+	if f.clientIP.enabled {
+		if ip := f.clientIP.ipString(f.clientIP.clientIP(req)); ip != "" {
+			attrs = append(attrs, slog.String("remoteIp", ip))
+		}
+	}
+
+	if req.Proto != "" {
+		attrs = append(attrs, slog.String("protocol", req.Proto))
+	}
+
+	return attrs
+}
+
+// gcpAttrs renders the response fields of a Cloud Logging "httpRequest" object.
+func (f httpResponseFormatter) gcpAttrs(res *http.Response) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.Int("status", res.StatusCode),
+	}
+
+	if res.ContentLength >= 0 {
+		attrs = append(attrs, slog.String("responseSize", strconv.FormatInt(res.ContentLength, 10)))
+	}
+
+	return attrs
+}
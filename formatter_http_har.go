@@ -0,0 +1,177 @@
+package slogformatter
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// HARNameValue is a name/value pair of a HAR document (headers, query string...).
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARCookie is a cookie of a HAR document.
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// HARPostData is the body of a HAR request.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARRequest is the request of a HAR entry.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARContent is the body of a HAR response. Text is omitted for binary bodies.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARResponse is the response of a HAR entry.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// harRequest renders a request as a HAR request. Cookies are always listed, and
+// masked like headers.
+func (f httpRequestFormatter) harRequest(req *http.Request) HARRequest {
+	u := absoluteRequestURL(req, f.urls.redactURL(req.URL))
+
+	cookies := []HARCookie{}
+	for _, cookie := range req.Cookies() {
+		cookies = append(cookies, HARCookie{Name: cookie.Name, Value: f.cookies.cookieValue(cookie)})
+	}
+
+	output := HARRequest{
+		Method:      req.Method,
+		URL:         urlString(u),
+		HTTPVersion: harHTTPVersion(req.Proto),
+		Cookies:     cookies,
+		Headers:     f.headers.harHeaders(req.Header),
+		QueryString: harQueryString(u.Query()),
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+
+	if b, ok := f.body.read(&req.Body, req.Header.Get("Content-Type")); ok {
+		if text, ok := f.body.text(b); ok {
+			output.PostData = &HARPostData{MimeType: b.contentType, Text: text}
+		}
+	}
+
+	return output
+}
+
+// harResponse renders a response as a HAR response.
+func (f httpResponseFormatter) harResponse(res *http.Response) HARResponse {
+	cookies := []HARCookie{}
+	for _, cookie := range res.Cookies() {
+		output := HARCookie{
+			Name:     cookie.Name,
+			Value:    f.cookies.cookieValue(cookie),
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			output.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+		cookies = append(cookies, output)
+	}
+
+	content := HARContent{
+		Size:     max(res.ContentLength, 0),
+		MimeType: res.Header.Get("Content-Type"),
+	}
+	if b, ok := f.body.read(&res.Body, content.MimeType); ok {
+		if res.ContentLength < 0 {
+			content.Size = int64(len(b.data))
+		}
+		if text, ok := f.body.text(b); ok {
+			content.Text = text
+		}
+	}
+
+	return HARResponse{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: harHTTPVersion(res.Proto),
+		Cookies:     cookies,
+		Headers:     f.headers.harHeaders(res.Header),
+		Content:     content,
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    res.ContentLength,
+	}
+}
+
+// harHeaders lists headers with one entry per value. Hidden headers give an empty list.
+func (f httpHeadersFilter) harHeaders(header http.Header) []HARNameValue {
+	output := []HARNameValue{}
+	if f.hide {
+		return output
+	}
+
+	keys, values := f.filter(header)
+	for _, key := range keys {
+		for _, value := range values[key] {
+			output = append(output, HARNameValue{Name: key, Value: value})
+		}
+	}
+	return output
+}
+
+func harQueryString(values url.Values) []HARNameValue {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	output := []HARNameValue{}
+	for _, key := range keys {
+		for _, value := range values[key] {
+			output = append(output, HARNameValue{Name: key, Value: value})
+		}
+	}
+	return output
+}
+
+func harHTTPVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
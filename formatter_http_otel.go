@@ -0,0 +1,192 @@
+package slogformatter
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"log/slog"
+)
+
+// otelValue renders a request with OpenTelemetry semantic conventions.
+// Attribute keys are flat and dot-separated, such as "http.request.method".
+func (f httpRequestFormatter) otelValue(req *http.Request) slog.Value {
+	u := f.urls.redactURL(req.URL)
+
+	attrs := []slog.Attr{
+		slog.String("http.request.method", req.Method),
+		slog.String("url.full", urlString(u)),
+		slog.String("url.scheme", u.Scheme),
+		slog.String("url.path", u.Path),
+		slog.String("url.query", u.RawQuery),
+		slog.String("url.fragment", u.Fragment),
+	}
+
+	host := req.Host
+	if host == "" {
+		host = u.Host
+	}
+	attrs = append(attrs, otelServerAttrs(host)...)
+	attrs = append(attrs, slog.String("user_agent.original", req.UserAgent()))
+
+	attrs = append(attrs, f.headers.otelAttrs("http.request.header.", req.Header)...)
+
+	if f.cookies.enabled {
+		attr := f.cookies.requestAttr(req.Cookies())
+		attr.Key = "http.request.cookies"
+		attrs = append(attrs, attr)
+	}
+
+	if f.opts.WithProtocol {
+		attrs = append(attrs, otelProtocolAttrs(req.ProtoMajor, req.ProtoMinor)...)
+		if req.ContentLength >= 0 {
+			attrs = append(attrs, slog.Int64("http.request.body.size", req.ContentLength))
+		}
+	}
+
+	if f.opts.WithTLS && req.TLS != nil {
+		attrs = append(attrs, otelTLSAttrs(req.TLS)...)
+	}
+
+	if f.opts.WithRoute {
+		attrs = append(attrs, slog.String("http.route", requestRoute(req)))
+	}
+
+	if f.opts.WithUserAgent {
+		ua := parseUserAgent(req.UserAgent())
+		attrs = append(
+			attrs,
+			slog.String("user_agent.name", ua.browser),
+			slog.String("user_agent.version", ua.browserVersion),
+			slog.String("user_agent.os.name", ua.os),
+		)
+		if ua.bot {
+			attrs = append(attrs, slog.String("user_agent.synthetic.type", "bot"))
+		}
+	}
+
+	if f.clientIP.enabled {
+		attrs = append(attrs, slog.String("client.address", f.clientIP.ipString(f.clientIP.clientIP(req))))
+
+		peer := f.clientIP.remoteAddrString(req.RemoteAddr)
+		if peerHost, peerPort, err := net.SplitHostPort(peer); err == nil {
+			attrs = append(attrs, slog.String("network.peer.address", peerHost))
+			if port, err := strconv.Atoi(peerPort); err == nil {
+				attrs = append(attrs, slog.Int("network.peer.port", port))
+			}
+		} else {
+			attrs = append(attrs, slog.String("network.peer.address", peer))
+		}
+	}
+
+	if attr, ok := f.body.attr(&req.Body, req.Header.Get("Content-Type")); ok {
+		attr.Key = "http.request.body"
+		attrs = append(attrs, attr)
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// otelValue renders a response with OpenTelemetry semantic conventions.
+func (f httpResponseFormatter) otelValue(res *http.Response) slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("http.response.status_code", res.StatusCode),
+	}
+
+	if res.ContentLength >= 0 {
+		attrs = append(attrs, slog.Int64("http.response.body.size", res.ContentLength))
+	}
+
+	attrs = append(attrs, f.headers.otelAttrs("http.response.header.", res.Header)...)
+
+	if f.cookies.enabled {
+		attr := f.cookies.responseAttr(res.Cookies())
+		attr.Key = "http.response.cookies"
+		attrs = append(attrs, attr)
+	}
+
+	if attr, ok := f.body.attr(&res.Body, res.Header.Get("Content-Type")); ok {
+		attr.Key = "http.response.body"
+		attrs = append(attrs, attr)
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// otelAttrs renders headers as "<prefix><lowercase name>" attributes holding
+// a []string. Hidden headers are omitted.
+func (f httpHeadersFilter) otelAttrs(prefix string, header http.Header) []slog.Attr {
+	if f.hide {
+		return nil
+	}
+
+	keys, values := f.filter(header)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(prefix+strings.ToLower(key), values[key]))
+	}
+	return attrs
+}
+
+func otelServerAttrs(host string) []slog.Attr {
+	if host == "" {
+		return nil
+	}
+
+	hostname, rawPort, err := net.SplitHostPort(host)
+	if err != nil {
+		return []slog.Attr{slog.String("server.address", strings.Trim(host, "[]"))}
+	}
+
+	attrs := []slog.Attr{slog.String("server.address", hostname)}
+	if port, err := strconv.Atoi(rawPort); err == nil {
+		attrs = append(attrs, slog.Int("server.port", port))
+	}
+	return attrs
+}
+
+func otelProtocolAttrs(major int, minor int) []slog.Attr {
+	version := strconv.Itoa(major)
+	if major < 2 {
+		version += "." + strconv.Itoa(minor)
+	}
+
+	return []slog.Attr{
+		slog.String("network.protocol.name", "http"),
+		slog.String("network.protocol.version", version),
+	}
+}
+
+func otelTLSAttrs(state *tls.ConnectionState) []slog.Attr {
+	name, version := tlsProtocol(state.Version)
+
+	return []slog.Attr{
+		slog.String("tls.protocol.name", name),
+		slog.String("tls.protocol.version", version),
+		slog.String("tls.cipher", tls.CipherSuiteName(state.CipherSuite)),
+		slog.String("tls.client.server_name", state.ServerName),
+		slog.String("tls.next_protocol", state.NegotiatedProtocol),
+	}
+}
+
+// tlsProtocol splits tls.VersionName into a protocol name and version, such as
+// "tls" and "1.3".
+func tlsProtocol(version uint16) (string, string) {
+	switch version {
+	case tls.VersionSSL30: //nolint:staticcheck
+		return "ssl", "3"
+	case tls.VersionTLS10:
+		return "tls", "1.0"
+	case tls.VersionTLS11:
+		return "tls", "1.1"
+	case tls.VersionTLS12:
+		return "tls", "1.2"
+	case tls.VersionTLS13:
+		return "tls", "1.3"
+	default:
+		return "", tls.VersionName(version)
+	}
+}
@@ -0,0 +1,109 @@
+package slogformatter
+
+import (
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRequestFormatterWithOptions_OTelSchema(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com:8443/v1/users?token=abcd&page=2#top", nil)
+	is.NoError(err)
+	req.ContentLength = 42
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Add("Accept", "text/html")
+	req.Header.Add("Accept", "application/json")
+	req.TLS = &tls.ConnectionState{
+		Version:     tls.VersionTLS12,
+		CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		ServerName:  "api.example.com",
+	}
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Schema:        HTTPSchemaOTel,
+		WithProtocol:  true,
+		WithTLS:       true,
+		WithUserAgent: true,
+		ClientIP:      HTTPClientIPOptions{Enabled: true},
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+	is.Equal(
+		[]slog.Attr{
+			slog.String("http.request.method", "POST"),
+			slog.String("url.full", "https://api.example.com:8443/v1/users?token=*******&page=2#top"),
+			slog.String("url.scheme", "https"),
+			slog.String("url.path", "/v1/users"),
+			slog.String("url.query", "token=*******&page=2"),
+			slog.String("url.fragment", "top"),
+			slog.String("server.address", "api.example.com"),
+			slog.Int("server.port", 8443),
+			slog.String("user_agent.original", "curl/8.4.0"),
+			slog.Any("http.request.header.accept", []string{"text/html", "application/json"}),
+			slog.Any("http.request.header.authorization", []string{"*******"}),
+			slog.Any("http.request.header.user-agent", []string{"curl/8.4.0"}),
+			slog.String("network.protocol.name", "http"),
+			slog.String("network.protocol.version", "1.1"),
+			slog.Int64("http.request.body.size", 42),
+			slog.String("tls.protocol.name", "tls"),
+			slog.String("tls.protocol.version", "1.2"),
+			slog.String("tls.cipher", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"),
+			slog.String("tls.client.server_name", "api.example.com"),
+			slog.String("tls.next_protocol", ""),
+			slog.String("user_agent.name", "curl"),
+			slog.String("user_agent.version", "8.4.0"),
+			slog.String("user_agent.os.name", ""),
+			slog.String("client.address", "203.0.113.7"),
+			slog.String("network.peer.address", "203.0.113.7"),
+			slog.Int("network.peer.port", 51234),
+		},
+		val.Group(),
+	)
+}
+
+func TestHTTPResponseFormatterWithOptions_OTelSchema(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	res := &http.Response{
+		StatusCode:    201,
+		ContentLength: 11,
+		Header:        http.Header{"Content-Type": []string{"text/plain"}},
+		Body:          io.NopCloser(strings.NewReader("hello world")),
+	}
+
+	formatter := HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{
+		Schema: HTTPSchemaOTel,
+		Body:   HTTPBodyOptions{MaxSize: 1024},
+	})
+
+	val, ok := formatter(nil, slog.Any("response", res))
+	is.True(ok)
+
+	attrs := val.Group()
+	is.Len(attrs, 4)
+	is.Equal(slog.Int("http.response.status_code", 201), attrs[0])
+	is.Equal(slog.Int64("http.response.body.size", 11), attrs[1])
+	is.Equal(slog.Any("http.response.header.content-type", []string{"text/plain"}), attrs[2])
+	is.Equal("http.response.body", attrs[3].Key)
+
+	// hidden headers are omitted
+	formatter = HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{
+		Schema:  HTTPSchemaOTel,
+		Headers: HTTPHeadersOptions{Hide: true},
+	})
+	val, ok = formatter(nil, slog.Any("response", res))
+	is.True(ok)
+	is.Len(val.Group(), 2)
+}
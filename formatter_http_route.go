@@ -0,0 +1,10 @@
+//go:build go1.23
+
+package slogformatter
+
+import "net/http"
+
+// requestRoute returns the pattern matched by http.ServeMux (Go >= 1.23).
+func requestRoute(req *http.Request) string {
+	return req.Pattern
+}
@@ -0,0 +1,11 @@
+//go:build !go1.23
+
+package slogformatter
+
+import "net/http"
+
+// requestRoute returns the pattern matched by http.ServeMux. http.Request.Pattern
+// is not available before Go 1.23.
+func requestRoute(_ *http.Request) string {
+	return ""
+}
@@ -0,0 +1,34 @@
+//go:build go1.23
+
+package slogformatter
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRequestFormatterWithOptions_Route(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{WithRoute: true})
+
+	var route string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		val, ok := formatter(nil, slog.Any("request", r))
+		is.True(ok)
+		for _, a := range val.Group() {
+			if a.Key == "route" {
+				route = a.Value.String()
+			}
+		}
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	is.Equal("GET /users/{id}", route)
+}
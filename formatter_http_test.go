@@ -2,9 +2,12 @@ package slogformatter
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"sync"
 	"sync/atomic"
 	"testing"
 
@@ -392,3 +395,215 @@ func TestHTTPResponseFormatter_ZeroStatus(t *testing.T) {
 	logger.Info("test", slog.Any("response", resp))
 	is.Equal(int32(1), atomic.LoadInt32(&checked))
 }
+
+func TestHTTPRequestFormatterWithOptions_Headers(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Scheme: "https", Host: "example.com", Path: "/"},
+		Header: http.Header{
+			"Authorization": []string{"Bearer secret"},
+			"x-api-key":     []string{"secret"},
+			"Content-Type":  []string{"application/json"},
+			"Accept":        []string{"text/html", "application/json"},
+			"X-Internal":    []string{"internal"},
+		},
+	}
+
+	headersOf := func(opts HTTPHeadersOptions) []slog.Attr {
+		formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{Headers: opts})
+		val, ok := formatter(nil, slog.Any("request", req))
+		is.True(ok)
+		for _, a := range val.Group() {
+			if a.Key == "headers" {
+				return a.Value.Group()
+			}
+		}
+		return nil
+	}
+
+	// sensitive headers are masked by default, names are canonicalized and sorted
+	is.Equal(
+		[]slog.Attr{
+			slog.String("Accept", "text/html,application/json"),
+			slog.String("Authorization", "*******"),
+			slog.String("Content-Type", "application/json"),
+			slog.String("X-Api-Key", "*******"),
+			slog.String("X-Internal", "internal"),
+		},
+		headersOf(HTTPHeadersOptions{}),
+	)
+
+	// allowlist and denylist, denylist takes precedence
+	is.Equal(
+		[]slog.Attr{
+			slog.String("Authorization", "*******"),
+			slog.String("Content-Type", "application/json"),
+		},
+		headersOf(HTTPHeadersOptions{
+			Allowlist: []string{"content-type", "authorization", "x-internal"},
+			Denylist:  []string{"X-INTERNAL"},
+		}),
+	)
+
+	// masking can be disabled
+	is.Equal(
+		[]slog.Attr{
+			slog.String("Authorization", "Bearer secret"),
+		},
+		headersOf(HTTPHeadersOptions{
+			Allowlist: []string{"Authorization"},
+			Sensitive: []string{},
+		}),
+	)
+}
+
+func TestHTTPResponseFormatterWithOptions_Headers(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{
+		Headers: HTTPHeadersOptions{Denylist: []string{"Server"}},
+	})
+
+	resp := &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Set-Cookie":   []string{"session=secret"},
+			"Server":       []string{"nginx"},
+			"Content-Type": []string{"text/html"},
+		},
+	}
+
+	val, ok := formatter(nil, slog.Any("response", resp))
+	is.True(ok)
+	for _, a := range val.Group() {
+		if a.Key == "headers" {
+			is.Equal(
+				[]slog.Attr{
+					slog.String("Content-Type", "text/html"),
+					slog.String("Set-Cookie", "*******"),
+				},
+				a.Value.Group(),
+			)
+		}
+	}
+}
+
+func TestHTTPRequestFormatter_ConcurrentLogging(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var checked int32
+	handler := NewFormatterMiddleware(HTTPRequestFormatter(false))
+
+	logger := slog.New(
+		handler(
+			slogmock.Option{
+				Handle: func(ctx context.Context, record slog.Record) error {
+					record.Attrs(func(attr slog.Attr) bool {
+						if attr.Key == "request" && attr.Value.Kind() == slog.KindGroup {
+							var host, header string
+							for _, a := range attr.Value.Group() {
+								switch a.Key {
+								case "host":
+									host = a.Value.String()
+								case "headers":
+									header = a.Value.Group()[0].Value.String()
+								}
+							}
+							is.Equal(host, header)
+							atomic.AddInt32(&checked, 1)
+						}
+						return true
+					})
+					return nil
+				},
+			}.NewMockHandler(),
+		),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			host := fmt.Sprintf("host-%d.example.com", i)
+			logger.Info("test", slog.Any("request", &http.Request{
+				Method: "GET",
+				Host:   host,
+				URL:    &url.URL{Host: host},
+				Header: http.Header{"X-Host": []string{host}},
+			}))
+		}(i)
+	}
+	wg.Wait()
+
+	is.Equal(int32(50), atomic.LoadInt32(&checked))
+}
+
+func TestHTTPRequestFormatterWithOptions_RichFields(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/users/42", nil)
+	is.NoError(err)
+	req.Proto = "HTTP/2.0"
+	req.ContentLength = 128
+	req.TransferEncoding = []string{"chunked"}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0")
+	req.TLS = &tls.ConnectionState{
+		Version:            tls.VersionTLS13,
+		CipherSuite:        tls.TLS_AES_128_GCM_SHA256,
+		ServerName:         "example.com",
+		NegotiatedProtocol: "h2",
+	}
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		WithProtocol:  true,
+		WithTLS:       true,
+		WithRoute:     true,
+		WithUserAgent: true,
+	})
+
+	val, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+
+	fields := map[string]slog.Value{}
+	for _, a := range val.Group() {
+		fields[a.Key] = a.Value
+	}
+
+	is.Equal("HTTP/2.0", fields["proto"].String())
+	is.Equal(int64(128), fields["content_length"].Int64())
+	is.Equal("chunked", fields["transfer_encoding"].String())
+	is.Equal("", fields["route"].String())
+	is.Equal(
+		[]slog.Attr{
+			slog.String("version", "TLS 1.3"),
+			slog.String("cipher_suite", "TLS_AES_128_GCM_SHA256"),
+			slog.String("server_name", "example.com"),
+			slog.String("negotiated_protocol", "h2"),
+		},
+		fields["tls"].Group(),
+	)
+	is.Equal(
+		[]slog.Attr{
+			slog.String("original", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"),
+			slog.String("browser", "Firefox"),
+			slog.String("browser_version", "121.0"),
+			slog.String("os", "Linux"),
+			slog.Bool("bot", false),
+		},
+		fields["user_agent"].Group(),
+	)
+
+	// fields are opt-in
+	val, ok = HTTPRequestFormatter(false)(nil, slog.Any("request", req))
+	is.True(ok)
+	for _, a := range val.Group() {
+		is.NotContains([]string{"proto", "tls", "route", "user_agent"}, a.Key)
+	}
+}
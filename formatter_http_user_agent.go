@@ -0,0 +1,122 @@
+package slogformatter
+
+import (
+	"strings"
+
+	"log/slog"
+)
+
+// userAgent is the result of a best-effort parsing of a User-Agent header.
+// It is not meant to be exhaustive: unknown browsers and operating systems are
+// left empty.
+type userAgent struct {
+	original       string
+	browser        string
+	browserVersion string
+	os             string
+	bot            bool
+}
+
+// Order matters: most user agents mention several products (eg: Edge
+// advertises "Chrome/" and "Safari/" too).
+var userAgentBrowsers = []struct {
+	name  string
+	token string
+}{
+	{"Edge", "Edg/"},
+	{"Edge", "EdgA/"},
+	{"Edge", "EdgiOS/"},
+	{"Edge", "Edge/"},
+	{"Opera", "OPR/"},
+	{"Samsung Internet", "SamsungBrowser/"},
+	{"Chrome", "CriOS/"},
+	{"Chrome", "Chrome/"},
+	{"Firefox", "FxiOS/"},
+	{"Firefox", "Firefox/"},
+	{"Safari", "Version/"},
+	{"Internet Explorer", "MSIE "},
+	{"Internet Explorer", "Trident/"},
+	{"curl", "curl/"},
+	{"Wget", "Wget/"},
+	{"Go-http-client", "Go-http-client/"},
+	{"python-requests", "python-requests/"},
+}
+
+var userAgentOperatingSystems = []struct {
+	name  string
+	token string
+}{
+	{"iOS", "iPhone"},
+	{"iOS", "iPad"},
+	{"Android", "Android"},
+	{"ChromeOS", "CrOS"},
+	{"Windows", "Windows"},
+	{"macOS", "Mac OS X"},
+	{"macOS", "Macintosh"},
+	{"Linux", "Linux"},
+}
+
+var userAgentBotTokens = []string{
+	"bot",
+	"crawler",
+	"spider",
+	"slurp",
+	"facebookexternalhit",
+	"headlesschrome",
+	"lighthouse",
+}
+
+func parseUserAgent(ua string) userAgent {
+	result := userAgent{original: ua}
+
+	for _, browser := range userAgentBrowsers {
+		if i := strings.Index(ua, browser.token); i >= 0 {
+			if browser.token == "Version/" && !strings.Contains(ua, "Safari/") {
+				continue
+			}
+
+			result.browser = browser.name
+			result.browserVersion = userAgentVersion(ua[i+len(browser.token):])
+			break
+		}
+	}
+
+	for _, os := range userAgentOperatingSystems {
+		if strings.Contains(ua, os.token) {
+			result.os = os.name
+			break
+		}
+	}
+
+	lower := strings.ToLower(ua)
+	for _, token := range userAgentBotTokens {
+		if strings.Contains(lower, token) {
+			result.bot = true
+			break
+		}
+	}
+
+	return result
+}
+
+// userAgentVersion returns the version following a product token, such as
+// "120.0.1" in "Chrome/120.0.1 Safari/537.36".
+func userAgentVersion(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == ';' || r == ')'
+	})
+	if end >= 0 {
+		return s[:end]
+	}
+	return s
+}
+
+func (ua userAgent) value() slog.Value {
+	return slog.GroupValue(
+		slog.String("original", ua.original),
+		slog.String("browser", ua.browser),
+		slog.String("browser_version", ua.browserVersion),
+		slog.String("os", ua.os),
+		slog.Bool("bot", ua.bot),
+	)
+}
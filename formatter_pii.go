@@ -1,91 +1,549 @@
 package slogformatter
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/netip"
+	"path"
 	"strings"
+	"unicode"
 
 	"log/slog"
 )
 
-// IPAddressFormatter transforms an IP address into "********".
+// IPAddressFormatterOptions configures IPAddressFormatterWithOptions.
+type IPAddressFormatterOptions struct {
+	// IPv4PrefixLength is the number of leading bits kept in IPv4 addresses, between 1 and 32.
+	// Default: 24 (the last octet is zeroed).
+	IPv4PrefixLength int
+	// IPv6PrefixLength is the number of leading bits kept in IPv6 addresses, between 1 and 128.
+	// Default: 48 (the last 80 bits are zeroed).
+	IPv6PrefixLength int
+	// Audit counts the anonymized values under the "ip_address" rule. Optional.
+	Audit *RedactionAudit
+}
+
+type ipAnonymizer struct {
+	ipv4Bits int
+	ipv6Bits int
+}
+
+func newIPAnonymizer(opts IPAddressFormatterOptions) ipAnonymizer {
+	if opts.IPv4PrefixLength == 0 {
+		opts.IPv4PrefixLength = 24
+	}
+	if opts.IPv6PrefixLength == 0 {
+		opts.IPv6PrefixLength = 48
+	}
+	if opts.IPv4PrefixLength < 1 || opts.IPv4PrefixLength > 32 {
+		panic("slog-formatter: IPv4 prefix length must be between 1 and 32")
+	}
+	if opts.IPv6PrefixLength < 1 || opts.IPv6PrefixLength > 128 {
+		panic("slog-formatter: IPv6 prefix length must be between 1 and 128")
+	}
+
+	return ipAnonymizer{
+		ipv4Bits: opts.IPv4PrefixLength,
+		ipv6Bits: opts.IPv6PrefixLength,
+	}
+}
+
+// anonymize returns the anonymized value, or "*******" when it is not an IP address.
+func (a ipAnonymizer) anonymize(v slog.Value) slog.Value {
+	v = v.Resolve()
+
+	switch ip := v.Any().(type) {
+	case string:
+		return slog.StringValue(a.anonymizeString(ip))
+	case netip.Addr:
+		if ip.IsValid() {
+			return slog.StringValue(anonymizeIP(ip, a.ipv4Bits, a.ipv6Bits).String())
+		}
+	case netip.AddrPort:
+		if ip.IsValid() {
+			return slog.StringValue(netip.AddrPortFrom(anonymizeIP(ip.Addr(), a.ipv4Bits, a.ipv6Bits), ip.Port()).String())
+		}
+	case net.IP:
+		if addr, ok := netip.AddrFromSlice(ip); ok {
+			return slog.StringValue(anonymizeIP(addr, a.ipv4Bits, a.ipv6Bits).String())
+		}
+	}
+
+	return slog.StringValue("*******")
+}
+
+// anonymizeString anonymizes an address, an "host:port" pair or a comma-separated
+// list of them, such as a X-Forwarded-For header. Items that are not IP addresses
+// are replaced by "*******".
+func (a ipAnonymizer) anonymizeString(s string) string {
+	if !strings.Contains(s, ",") {
+		return a.anonymizeItem(strings.TrimSpace(s))
+	}
+
+	items := strings.Split(s, ",")
+	for i, item := range items {
+		items[i] = a.anonymizeItem(strings.TrimSpace(item))
+	}
+	return strings.Join(items, ", ")
+}
+
+func (a ipAnonymizer) anonymizeItem(s string) string {
+	if addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")); err == nil {
+		return anonymizeIP(addr, a.ipv4Bits, a.ipv6Bits).String()
+	}
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return netip.AddrPortFrom(anonymizeIP(addrPort.Addr(), a.ipv4Bits, a.ipv6Bits), addrPort.Port()).String()
+	}
+	return "*******"
+}
+
+// IPAddressFormatter anonymizes IP addresses, zeroing the last octet of IPv4
+// addresses (/24) and the last 80 bits of IPv6 addresses (/48).
+// See IPAddressFormatterWithOptions.
+func IPAddressFormatter(key string) Formatter {
+	return IPAddressFormatterWithOptions(key, IPAddressFormatterOptions{})
+}
+
+// IPAddressFormatterWithOptions anonymizes IP addresses by keeping only their
+// network prefix. It supports net.IP, netip.Addr, netip.AddrPort, and strings
+// holding an address, an "host:port" pair or a X-Forwarded-For list. Other
+// values are replaced by "*******".
 //
 // Example:
 //
 //	"context": {
-//	  "ip_address": "bd57ffbd-8858-4cc4-a93b-426cef16de61"
+//	  "ip_address": "192.168.1.42",
+//	  "forwarded_for": "203.0.113.7, [2001:db8:85a3::8a2e:370:7334]:443"
 //	}
 //
-// passed to IPAddressFormatter("ip_address"), will be transformed into:
+// passed to IPAddressFormatter("ip_address") and IPAddressFormatter("forwarded_for"),
+// will be transformed into:
 //
 //	"context": {
-//	  "ip_address": "********",
+//	  "ip_address": "192.168.1.0",
+//	  "forwarded_for": "203.0.113.0, [2001:db8:85a3::]:443"
 //	}
-func IPAddressFormatter(key string) Formatter {
-	return FormatByKey(key, func(v slog.Value) slog.Value {
-		return slog.StringValue("*******")
+//
+// It panics when the prefix lengths are out of range.
+func IPAddressFormatterWithOptions(key string, opts IPAddressFormatterOptions) Formatter {
+	anonymizer := newIPAnonymizer(opts)
+	return formatByKeyPath(key, func(groups []string, key string, v slog.Value) slog.Value {
+		if opts.Audit != nil {
+			opts.Audit.add("ip_address", joinPath(groups, key))
+		}
+		return anonymizer.anonymize(v)
 	})
 }
 
+// anonymizeIP keeps the first bits of an IP address and zeroes the others.
+// It returns an invalid address when the prefix length is out of range.
+func anonymizeIP(addr netip.Addr, ipv4Bits int, ipv6Bits int) netip.Addr {
+	addr = addr.Unmap()
+
+	bits := ipv6Bits
+	if addr.Is4() {
+		bits = ipv4Bits
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return prefix.Addr()
+}
+
+// PIIStrategy masks a value of a PII subtree.
+type PIIStrategy func(v slog.Value) slog.Value
+
+// PIIStrategyDefault keeps the first 4 characters of strings longer than 5
+// characters and appends "*******". Other values are replaced by "*******".
+// Characters are grapheme clusters: accented letters and emojis are never cut.
+func PIIStrategyDefault() PIIStrategy {
+	return func(v slog.Value) slog.Value {
+		if v.Kind() != slog.KindString {
+			return slog.StringValue("*******")
+		}
+
+		s := strings.ToValidUTF8(v.String(), "\uFFFD")
+		boundaries := graphemeBoundaries(s)
+		if len(boundaries)-1 <= 5 {
+			return slog.StringValue("*******")
+		}
+
+		return slog.StringValue(s[:boundaries[4]] + "*******")
+	}
+}
+
+// PIIStrategyMask replaces any value by "*******".
+func PIIStrategyMask() PIIStrategy {
+	return func(v slog.Value) slog.Value {
+		return slog.StringValue("*******")
+	}
+}
+
+// PIIStrategyKeepPrefix keeps the first n characters of strings and appends "*******".
+// Non-string values and strings of n characters or less are replaced by "*******".
+//
+// It panics when n is negative.
+func PIIStrategyKeepPrefix(n int) PIIStrategy {
+	mustPIIStrategyLength(n)
+	return func(v slog.Value) slog.Value {
+		if v.Kind() != slog.KindString {
+			return slog.StringValue("*******")
+		}
+
+		s := strings.ToValidUTF8(v.String(), "\uFFFD")
+		boundaries := graphemeBoundaries(s)
+		if len(boundaries)-1 <= n {
+			return slog.StringValue("*******")
+		}
+
+		return slog.StringValue(s[:boundaries[n]] + "*******")
+	}
+}
+
+// PIIStrategyKeepSuffix keeps the last n characters of strings, prefixed by "*******".
+// Non-string values and strings of n characters or less are replaced by "*******".
+//
+// It panics when n is negative.
+func PIIStrategyKeepSuffix(n int) PIIStrategy {
+	mustPIIStrategyLength(n)
+	return func(v slog.Value) slog.Value {
+		if v.Kind() != slog.KindString {
+			return slog.StringValue("*******")
+		}
+
+		s := strings.ToValidUTF8(v.String(), "\uFFFD")
+		boundaries := graphemeBoundaries(s)
+		if len(boundaries)-1 <= n {
+			return slog.StringValue("*******")
+		}
+
+		return slog.StringValue("*******" + s[boundaries[len(boundaries)-1-n]:])
+	}
+}
+
+// PIIStrategyFixedMask replaces any value by n "*", hiding its length.
+//
+// It panics when n is negative.
+func PIIStrategyFixedMask(n int) PIIStrategy {
+	mustPIIStrategyLength(n)
+	mask := strings.Repeat("*", n)
+	return func(v slog.Value) slog.Value {
+		return slog.StringValue(mask)
+	}
+}
+
+func mustPIIStrategyLength(n int) {
+	if n < 0 {
+		panic("slog-formatter: PII strategy length must not be negative")
+	}
+}
+
+// PIIStrategyPreserveLength replaces every character of the value by "*".
+func PIIStrategyPreserveLength() PIIStrategy {
+	return func(v slog.Value) slog.Value {
+		return slog.StringValue(strings.Repeat("*", len(graphemeBoundaries(v.String()))-1))
+	}
+}
+
+// PIIStrategyHash replaces the value by the hex-encoded SHA-256 of its string
+// representation. Equal values give equal hashes, so that entries can be correlated.
+// Low-entropy values (phone numbers, birth dates...) can be recovered by brute force.
+func PIIStrategyHash() PIIStrategy {
+	return func(v slog.Value) slog.Value {
+		sum := sha256.Sum256([]byte(v.String()))
+		return slog.StringValue(hex.EncodeToString(sum[:]))
+	}
+}
+
+// PIIStrategyNull replaces any value by null.
+func PIIStrategyNull() PIIStrategy {
+	return func(v slog.Value) slog.Value {
+		return slog.AnyValue(nil)
+	}
+}
+
+// graphemeBoundaries returns the byte offsets of the user-perceived characters of
+// s, followed by len(s). It approximates the Unicode grapheme cluster rules:
+// combining marks, variation selectors, emoji modifiers, zero width joiner
+// sequences and regional indicator pairs are kept with their base character.
+// Invalid bytes are single characters.
+func graphemeBoundaries(s string) []int {
+	boundaries := make([]int, 0, len(s)+1)
+
+	joined := false
+	regionalIndicators := 0
+	for i, r := range s {
+		extend := unicode.Is(unicode.M, r) ||
+			unicode.Is(unicode.Variation_Selector, r) ||
+			(r >= 0x1F3FB && r <= 0x1F3FF) || // emoji modifiers
+			r == 0x200D // zero width joiner
+
+		isRegionalIndicator := r >= 0x1F1E6 && r <= 0x1F1FF
+		pairsRegionalIndicator := isRegionalIndicator && regionalIndicators%2 == 1
+
+		if !(extend || joined || pairsRegionalIndicator) || len(boundaries) == 0 {
+			boundaries = append(boundaries, i)
+		}
+
+		joined = r == 0x200D
+		if isRegionalIndicator {
+			regionalIndicators++
+		} else if !extend {
+			regionalIndicators = 0
+		}
+	}
+
+	return append(boundaries, len(s))
+}
+
+// PIIRule selects the strategy of the attributes matching a key pattern.
+type PIIRule struct {
+	// Pattern matches attribute keys, with the path.Match syntax. Matching is case-insensitive.
+	Pattern string
+	// Strategy masks the matching attributes. When the attribute is a group, the
+	// strategy applies to all its attributes, unless a nested rule matches. Required.
+	Strategy PIIStrategy
+}
+
+// PIIFormatterOptions configures PIIFormatterWithOptions.
+//
+// Preserve and Mask decide which attributes are masked, in this order:
+//
+//  1. attributes matching a Mask pattern are masked;
+//  2. attributes matching a Preserve pattern are kept as is;
+//  3. other attributes are masked.
+//
+// Preserve and Mask patterns use the path.Match syntax and match the keys of
+// non-group attributes. Keys are compared in lowercase and, for camelCase keys,
+// in snake_case too: "userId" matches "*_id".
+type PIIFormatterOptions struct {
+	// Strategy masks the attributes not matching any rule. Default: PIIStrategyDefault().
+	Strategy PIIStrategy
+	// Rules select a strategy by attribute key. The first matching rule wins.
+	// The formatter panics on invalid patterns.
+	Rules []PIIRule
+	// Preserve are the key patterns of identifiers kept as is.
+	// When nil, DefaultPIIPreservePatterns is used. Use an empty slice to mask everything.
+	Preserve []string
+	// Mask are the key patterns always masked, even when matching Preserve.
+	// When nil, DefaultPIIMaskPatterns is used.
+	Mask []string
+	// Audit counts the masked values, under the "pii" rule or the "pii:<pattern>"
+	// rule of the matching PIIRule. Optional.
+	Audit *RedactionAudit
+}
+
+// DefaultPIIPreservePatterns lists the identifiers kept as is by PIIFormatter.
+var DefaultPIIPreservePatterns = []string{
+	"id",
+	"*_id",
+	"uuid",
+	"*_uuid",
+}
+
+// DefaultPIIMaskPatterns lists the identifiers masked by PIIFormatter, although
+// they match DefaultPIIPreservePatterns.
+var DefaultPIIMaskPatterns = []string{
+	"national_id",
+	"tax_id",
+	"passport_id",
+	"driver_license_id",
+	"social_security_id",
+}
+
+type piiMasker struct {
+	strategy         PIIStrategy
+	rules            []PIIRule
+	preservePatterns []string
+	maskPatterns     []string
+	audit            *RedactionAudit
+}
+
+func newPIIMasker(opts PIIFormatterOptions) piiMasker {
+	if opts.Strategy == nil {
+		opts.Strategy = PIIStrategyDefault()
+	}
+
+	rules := make([]PIIRule, 0, len(opts.Rules))
+	for _, rule := range opts.Rules {
+		pattern := strings.ToLower(rule.Pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic("slog-formatter: invalid PII pattern: " + rule.Pattern)
+		}
+		if rule.Strategy == nil {
+			panic("slog-formatter: missing PII rule strategy: " + rule.Pattern)
+		}
+		rules = append(rules, PIIRule{Pattern: pattern, Strategy: rule.Strategy})
+	}
+
+	if opts.Preserve == nil {
+		opts.Preserve = DefaultPIIPreservePatterns
+	}
+	if opts.Mask == nil {
+		opts.Mask = DefaultPIIMaskPatterns
+	}
+
+	return piiMasker{
+		strategy:         opts.Strategy,
+		rules:            rules,
+		preservePatterns: piiPatterns(opts.Preserve),
+		maskPatterns:     piiPatterns(opts.Mask),
+		audit:            opts.Audit,
+	}
+}
+
+func piiPatterns(patterns []string) []string {
+	output := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic("slog-formatter: invalid PII pattern: " + pattern)
+		}
+		output = append(output, strings.ToLower(pattern))
+	}
+	return output
+}
+
+// matchPIIKey reports whether the lowercase or snake_case form of the key matches
+// one of the patterns.
+func matchPIIKey(patterns []string, key string) bool {
+	lower := strings.ToLower(key)
+	snake := snakeCase(key)
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, lower); ok {
+			return true
+		}
+		if snake != lower {
+			if ok, _ := path.Match(pattern, snake); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// snakeCase converts camelCase keys and dashes: "userID" and "user-id" become "user_id".
+func snakeCase(key string) string {
+	var b strings.Builder
+	b.Grow(len(key) + 4)
+
+	runes := []rune(key)
+	for i, r := range runes {
+		if r == '-' {
+			b.WriteByte('_')
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
 // PIIFormatter transforms any value under provided key into "********".
 // IDs are kept as is.
 //
 // Example:
 //
-//	  "user": {
-//	    "id": "bd57ffbd-8858-4cc4-a93b-426cef16de61",
-//	    "email": "foobar@example.com",
-//	    "address": {
-//	      "street": "1st street",
-//		     "city": "New York",
-//	      "country": USA",
-//		     "zip": 123456
-//	    }
+//	"user": {
+//	  "id": "bd57ffbd-8858-4cc4-a93b-426cef16de61",
+//	  "email": "foobar@example.com",
+//	  "address": {
+//	    "street": "1st street",
+//	    "city": "New York",
+//	    "country": "USA",
+//	    "zip": 123456
 //	  }
+//	}
 //
 // passed to PIIFormatter("user"), will be transformed into:
 //
-//	  "user": {
-//	    "id": "bd57ffbd-8858-4cc4-a93b-426cef16de61",
-//	    "email": "foob********",
-//	    "address": {
-//	      "street": "1st *******",
-//		     "city": "New *******",
-//	  	   "country": "*******",
-//		     "zip": "*******"
-//	    }
+//	"user": {
+//	  "id": "bd57ffbd-8858-4cc4-a93b-426cef16de61",
+//	  "email": "foob*******",
+//	  "address": {
+//	    "street": "1st *******",
+//	    "city": "New *******",
+//	    "country": "*******",
+//	    "zip": "*******"
 //	  }
+//	}
 func PIIFormatter(key string) Formatter {
-	return FormatByKey(key, func(v slog.Value) slog.Value {
-		return recursivelyHidePII(key, v)
+	return PIIFormatterWithOptions(key, PIIFormatterOptions{})
+}
+
+// PIIFormatterWithOptions transforms any value under provided key, with a
+// strategy selected by attribute key. IDs are kept as is.
+//
+// Example:
+//
+//	PIIFormatterWithOptions("user", PIIFormatterOptions{
+//	    Strategy: PIIStrategyMask(),
+//	    Rules: []PIIRule{
+//	        {Pattern: "email", Strategy: PIIStrategyHash()},
+//	        {Pattern: "*phone*", Strategy: PIIStrategyKeepSuffix(2)},
+//	        {Pattern: "address", Strategy: PIIStrategyNull()},
+//	    },
+//	})
+func PIIFormatterWithOptions(key string, opts PIIFormatterOptions) Formatter {
+	masker := newPIIMasker(opts)
+
+	return formatByKeyPath(key, func(groups []string, key string, v slog.Value) slog.Value {
+		var path string
+		if masker.audit != nil {
+			path = joinPath(groups, key)
+		}
+		return masker.mask(path, key, v, masker.strategy, "pii")
 	})
 }
 
-func recursivelyHidePII(key string, v slog.Value) slog.Value {
+// strategyFor returns the strategy and the rule name of the first rule matching
+// the key, or the inherited ones.
+func (m piiMasker) strategyFor(key string, inherited PIIStrategy, inheritedRule string) (PIIStrategy, string) {
+	key = strings.ToLower(key)
+	for _, rule := range m.rules {
+		if ok, _ := path.Match(rule.Pattern, key); ok {
+			return rule.Strategy, "pii:" + rule.Pattern
+		}
+	}
+	return inherited, inheritedRule
+}
+
+// mask masks the value of an attribute. The path of the attribute is only
+// tracked when auditing.
+func (m piiMasker) mask(attrPath string, key string, v slog.Value, strategy PIIStrategy, rule string) slog.Value {
 	if v.Kind() == slog.KindLogValuer {
 		v = v.LogValuer().LogValue()
 	}
 
+	strategy, rule = m.strategyFor(key, strategy, rule)
+
 	if v.Kind() == slog.KindGroup {
 		group := v.Group()
 		attrs := make([]slog.Attr, len(group))
 		for i, item := range group {
+			var itemPath string
+			if m.audit != nil {
+				itemPath = attrPath + "." + item.Key
+			}
 			// Use Attr literal instead of slog.Any to avoid boxing the already-resolved slog.Value into any.
-		attrs[i] = slog.Attr{Key: item.Key, Value: recursivelyHidePII(item.Key, item.Value)}
+			attrs[i] = slog.Attr{Key: item.Key, Value: m.mask(itemPath, item.Key, item.Value, strategy, rule)}
 		}
 		return slog.GroupValue(attrs...)
 	}
 
-	key = strings.ToLower(key)
-	if key == "id" || strings.HasSuffix(key, "_id") || strings.HasSuffix(key, "-id") {
+	if !matchPIIKey(m.maskPatterns, key) && matchPIIKey(m.preservePatterns, key) {
 		return v
 	}
 
-	if v.Kind() != slog.KindString {
-		return slog.StringValue("*******")
-	}
-
-	if len(v.String()) <= 5 {
-		return slog.StringValue("*******")
-	}
-
-	return slog.StringValue(v.String()[0:4] + "*******")
+	m.audit.add(rule, attrPath)
+	return strategy(v)
 }
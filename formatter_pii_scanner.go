@@ -0,0 +1,549 @@
+package slogformatter
+
+import (
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"log/slog"
+)
+
+// PIIDetector finds PII in a string.
+type PIIDetector struct {
+	// Name identifies the detector, such as "email".
+	Name string
+	// Find returns the [start, end) byte offsets of the matches, as regexp.FindAllStringIndex.
+	Find func(s string) [][]int
+}
+
+// NewPIIRegexpDetector returns a detector matching a regular expression. When
+// validate is not nil, matches rejected by validate are ignored.
+func NewPIIRegexpDetector(name string, re *regexp.Regexp, validate func(match string) bool) PIIDetector {
+	return PIIDetector{
+		Name: name,
+		Find: func(s string) [][]int {
+			matches := re.FindAllStringIndex(s, -1)
+			if validate == nil {
+				return matches
+			}
+
+			output := matches[:0]
+			for _, match := range matches {
+				if validate(s[match[0]:match[1]]) {
+					output = append(output, match)
+				}
+			}
+			return output
+		},
+	}
+}
+
+// newPIIGroupedDetector returns a detector matching a regular expression, whose
+// matches are made of alphanumeric groups, such as "4111 1111 1111 1111". Since a
+// match may include neighbouring groups, such as the "1" of "4111111111111111 1",
+// the runs of contiguous groups of each match are validated, from the leftmost and
+// longest one. Runs longer than maxLen bytes are skipped.
+func newPIIGroupedDetector(name string, re *regexp.Regexp, maxLen int, validate func(match string) bool) PIIDetector {
+	return PIIDetector{
+		Name: name,
+		Find: func(s string) [][]int {
+			var output [][]int
+			for _, match := range re.FindAllStringIndex(s, -1) {
+				output = append(output, findValidGroupRuns(s, match[0], match[1], maxLen, validate)...)
+			}
+			return output
+		},
+	}
+}
+
+func findValidGroupRuns(s string, start int, end int, maxLen int, validate func(match string) bool) [][]int {
+	// [start, end) offsets of the alphanumeric groups
+	var groups [][2]int
+	for i := start; i < end; {
+		if !isASCIIAlnum(s[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < end && isASCIIAlnum(s[j]) {
+			j++
+		}
+		groups = append(groups, [2]int{i, j})
+		i = j
+	}
+
+	var output [][]int
+	for i := 0; i < len(groups); {
+		found := false
+
+		last := i
+		for last+1 < len(groups) && groups[last+1][1]-groups[i][0] <= maxLen {
+			last++
+		}
+
+		for j := last; j >= i; j-- {
+			if validate(s[groups[i][0]:groups[j][1]]) {
+				output = append(output, []int{groups[i][0], groups[j][1]})
+				i = j + 1
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			i++
+		}
+	}
+
+	return output
+}
+
+func isASCIIAlnum(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// prefilterPIIDetector skips the detector when a cheap precondition fails.
+func prefilterPIIDetector(detector PIIDetector, precondition func(s string) bool) PIIDetector {
+	find := detector.Find
+	detector.Find = func(s string) [][]int {
+		if !precondition(s) {
+			return nil
+		}
+		return find(s)
+	}
+	return detector
+}
+
+func countDigits(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	// PIIDetectorEmail detects email addresses.
+	PIIDetectorEmail = PIIDetector{
+		Name: "email",
+		Find: findEmails,
+	}
+	// PIIDetectorCreditCard detects payment card numbers passing the Luhn checksum.
+	PIIDetectorCreditCard = prefilterPIIDetector(
+		newPIIGroupedDetector(
+			"credit_card",
+			regexp.MustCompile(`\b\d(?:[ -]?\d){12,}\b`),
+			19+18, // digits and separators
+			isLuhnValid,
+		),
+		func(s string) bool { return countDigits(s) >= 13 },
+	)
+	// PIIDetectorIBAN detects International Bank Account Numbers passing the ISO 7064 checksum.
+	PIIDetectorIBAN = prefilterPIIDetector(
+		newPIIGroupedDetector(
+			"iban",
+			regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,}\b`),
+			34+33, // characters and spaces
+			isIBANValid,
+		),
+		func(s string) bool { return countDigits(s) >= 4 },
+	)
+	// PIIDetectorPhone detects international phone numbers ("+33 6 12 34 56 78")
+	// and North American phone numbers ("(555) 123-4567").
+	PIIDetectorPhone = prefilterPIIDetector(
+		NewPIIRegexpDetector(
+			"phone",
+			regexp.MustCompile(`\+\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,6}|\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`),
+			isPhoneValid,
+		),
+		func(s string) bool { return countDigits(s) >= 8 },
+	)
+	// PIIDetectorUSSSN detects US Social Security Numbers ("123-45-6789").
+	PIIDetectorUSSSN = prefilterPIIDetector(
+		NewPIIRegexpDetector(
+			"us_ssn",
+			regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+			isUSSSNValid,
+		),
+		func(s string) bool { return strings.IndexByte(s, '-') > 0 && countDigits(s) >= 9 },
+	)
+	// PIIDetectorIPv4 detects IPv4 addresses.
+	PIIDetectorIPv4 = prefilterPIIDetector(
+		NewPIIRegexpDetector(
+			"ipv4",
+			regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+			func(match string) bool {
+				_, err := netip.ParseAddr(match)
+				return err == nil
+			},
+		),
+		func(s string) bool { return countDigits(s) >= 4 && strings.Count(s, ".") >= 3 },
+	)
+	// PIIDetectorIPv6 detects IPv6 addresses.
+	PIIDetectorIPv6 = prefilterPIIDetector(
+		PIIDetector{
+			Name: "ipv6",
+			Find: findIPv6,
+		},
+		func(s string) bool { return strings.Count(s, ":") >= 2 },
+	)
+	// PIIDetectorJWT detects JSON Web Tokens.
+	PIIDetectorJWT = prefilterPIIDetector(
+		NewPIIRegexpDetector(
+			"jwt",
+			regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+			nil,
+		),
+		func(s string) bool { return strings.Contains(s, "eyJ") },
+	)
+	// PIIDetectorAPIKey detects API keys with a well-known prefix: Stripe, GitHub,
+	// GitLab, Slack, AWS access keys, Google API keys and OpenAI.
+	PIIDetectorAPIKey = prefilterPIIDetector(
+		NewPIIRegexpDetector(
+			"api_key",
+			regexp.MustCompile(`\b(?:(?:sk|pk|rk)_(?:live|test)_[0-9A-Za-z]{10,}|gh[pousr]_[0-9A-Za-z]{30,}|github_pat_[0-9A-Za-z_]{22,}|glpat-[0-9A-Za-z_-]{20,}|xox[abprs]-[0-9A-Za-z-]{10,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35}|sk-[0-9A-Za-z_-]{20,})`),
+			nil,
+		),
+		func(s string) bool {
+			for _, prefix := range []string{"_live_", "_test_", "gh", "glpat-", "xox", "AKIA", "AIza", "sk-"} {
+				if strings.Contains(s, prefix) {
+					return true
+				}
+			}
+			return false
+		},
+	)
+)
+
+var emailDomainRegexp = regexp.MustCompile(`^[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)*\.\p{L}{2,}`)
+
+// findEmails looks for "@" and then expands the match to the local part and the
+// domain, which is much faster than an unanchored regular expression.
+func findEmails(s string) [][]int {
+	var matches [][]int
+
+	for offset := 0; offset < len(s); {
+		at := strings.IndexByte(s[offset:], '@')
+		if at < 0 {
+			break
+		}
+		at += offset
+		offset = at + 1
+
+		start := at
+		for start > 0 {
+			r, size := utf8.DecodeLastRuneInString(s[:start])
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("._%+-", r) {
+				break
+			}
+			start -= size
+		}
+		if start == at {
+			continue
+		}
+
+		if domain := emailDomainRegexp.FindStringIndex(s[at+1:]); domain != nil {
+			offset = at + 1 + domain[1]
+			matches = append(matches, []int{start, offset})
+		}
+	}
+
+	return matches
+}
+
+var ipv6Regexp = regexp.MustCompile(`(?i)[0-9a-f]{0,4}:[0-9a-f:]*:[0-9a-f.]*(?:%[0-9a-z_.\-]+)?`)
+
+// findIPv6 validates the candidates of ipv6Regexp, which must stand alone: "::" is
+// also the scope operator of many languages, such as in "std::vector" or
+// "Users::SessionsController". Zone IDs, such as "%eth0", are part of the match.
+func findIPv6(s string) [][]int {
+	var output [][]int
+
+	for _, match := range ipv6Regexp.FindAllStringIndex(s, -1) {
+		start, end := match[0], match[1]
+		// a trailing dot ends the sentence
+		for end > start && s[end-1] == '.' {
+			end--
+		}
+
+		if start > 0 {
+			r, _ := utf8.DecodeLastRuneInString(s[:start])
+			if isWordRune(r) || r == ':' || r == '.' {
+				continue
+			}
+		}
+		if end < len(s) {
+			r, _ := utf8.DecodeRuneInString(s[end:])
+			if isWordRune(r) || r == ':' {
+				continue
+			}
+		}
+
+		if isIPv6Valid(s[start:end]) {
+			output = append(output, []int{start, end})
+		}
+	}
+
+	return output
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// isIPv6Valid parses the address. Short compressed forms, such as "a::b", are
+// rejected unless they start with "::": an address must hold a group of at least
+// 3 hex digits, such as "fe80::1".
+func isIPv6Valid(match string) bool {
+	addr, err := netip.ParseAddr(match)
+	if err != nil || !addr.Is6() {
+		return false
+	}
+
+	if strings.HasPrefix(match, "::") {
+		return true
+	}
+
+	host, _, _ := strings.Cut(match, "%")
+	for _, group := range strings.Split(host, ":") {
+		if len(group) >= 3 {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultPIIDetectors lists the detectors used by PIIScannerFormatter.
+var DefaultPIIDetectors = []PIIDetector{
+	PIIDetectorJWT,
+	PIIDetectorAPIKey,
+	PIIDetectorEmail,
+	PIIDetectorIBAN,
+	PIIDetectorCreditCard,
+	PIIDetectorUSSSN,
+	PIIDetectorPhone,
+	PIIDetectorIPv4,
+	PIIDetectorIPv6,
+}
+
+// PIIScannerOptions configures PIIScannerFormatterWithOptions.
+type PIIScannerOptions struct {
+	// Detectors find PII in string values. When nil, DefaultPIIDetectors is used.
+	// Custom detectors can be appended to DefaultPIIDetectors.
+	Detectors []PIIDetector
+	// Replace returns the replacement of a match. Default: "*******".
+	Replace func(detector string, match string) string
+	// Audit counts the replaced matches under the "pii_scanner:<detector>" rules. Optional.
+	Audit *RedactionAudit
+}
+
+type piiScanner struct {
+	detectors []PIIDetector
+	replace   func(detector string, match string) string
+	audit     *RedactionAudit
+}
+
+func newPIIScanner(opts PIIScannerOptions) piiScanner {
+	if opts.Detectors == nil {
+		opts.Detectors = DefaultPIIDetectors
+	}
+	if opts.Replace == nil {
+		opts.Replace = func(string, string) string {
+			return "*******"
+		}
+	}
+
+	return piiScanner{
+		detectors: opts.Detectors,
+		replace:   opts.Replace,
+		audit:     opts.Audit,
+	}
+}
+
+type piiSpan struct {
+	start    int
+	end      int
+	detector string
+}
+
+// scan replaces the PII found in the input, located at path for auditing.
+// Overlapping matches are merged, and the merged span is attributed to the first
+// detector.
+func (s piiScanner) scan(path string, input string) string {
+	// the shortest built-in detection is an IPv6 address such as "::1"
+	if len(input) < 3 {
+		return input
+	}
+
+	var spans []piiSpan
+	for _, detector := range s.detectors {
+		for _, match := range detector.Find(input) {
+			spans = append(spans, piiSpan{start: match[0], end: match[1], detector: detector.Name})
+		}
+	}
+	if len(spans) == 0 {
+		return input
+	}
+
+	slices.SortStableFunc(spans, func(a, b piiSpan) int {
+		return a.start - b.start
+	})
+
+	merged := spans[:1]
+	for _, span := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if span.start < last.end {
+			last.end = max(last.end, span.end)
+			continue
+		}
+		merged = append(merged, span)
+	}
+
+	var b strings.Builder
+	b.Grow(len(input))
+	cursor := 0
+	for _, span := range merged {
+		b.WriteString(input[cursor:span.start])
+		b.WriteString(s.replace(span.detector, input[span.start:span.end]))
+		if s.audit != nil {
+			s.audit.add("pii_scanner:"+span.detector, path)
+		}
+		cursor = span.end
+	}
+	b.WriteString(input[cursor:])
+
+	return b.String()
+}
+
+// PIIScannerFormatter scans every string value, including nested ones, and
+// replaces the PII found by DefaultPIIDetectors with "*******". Unlike PIIFormatter,
+// it does not depend on attribute keys: only the matching parts of a string are replaced.
+//
+// Example:
+//
+//	"details": "refund sent to foo@example.com, card 4111 1111 1111 1111"
+//
+// will be transformed into:
+//
+//	"details": "refund sent to *******, card *******"
+func PIIScannerFormatter() Formatter {
+	return PIIScannerFormatterWithOptions(PIIScannerOptions{})
+}
+
+// PIIScannerFormatterWithOptions scans every string value with custom detectors.
+//
+// Example:
+//
+//	PIIScannerFormatterWithOptions(PIIScannerOptions{
+//	    Detectors: append(
+//	        slices.Clone(DefaultPIIDetectors),
+//	        NewPIIRegexpDetector("customer_ref", regexp.MustCompile(`\bCUS-\d{8}\b`), nil),
+//	    ),
+//	    Replace: func(detector string, match string) string {
+//	        return "[" + detector + "]"
+//	    },
+//	})
+func PIIScannerFormatterWithOptions(opts PIIScannerOptions) Formatter {
+	scanner := newPIIScanner(opts)
+
+	return formatByKindPath(slog.KindString, func(groups []string, key string, v slog.Value) slog.Value {
+		var path string
+		if scanner.audit != nil {
+			path = joinPath(groups, key)
+		}
+		return slog.StringValue(scanner.scan(path, v.String()))
+	})
+}
+
+// PIIRedactor replaces the PII found in free text, such as a log message or an
+// error string. The path locates the text, e.g. "msg", for audits.
+type PIIRedactor func(path string, s string) string
+
+// PIIScannerRedactor returns a PIIRedactor applying the detectors of the options,
+// so that messages can be redacted with the rules of PIIScannerFormatterWithOptions.
+// See FormatterHandlerOptions and ErrorFormatterOptions.
+func PIIScannerRedactor(opts PIIScannerOptions) PIIRedactor {
+	return newPIIScanner(opts).scan
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// isLuhnValid checks the length and the Luhn checksum of a card number.
+func isLuhnValid(match string) bool {
+	number := digits(match)
+	if len(number) < 13 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isIBANValid checks the length and the ISO 7064 mod 97-10 checksum of an IBAN.
+func isIBANValid(match string) bool {
+	iban := strings.ReplaceAll(match, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	if !isUpperASCII(iban[0]) || !isUpperASCII(iban[1]) || !isDigitASCII(iban[2]) || !isDigitASCII(iban[3]) {
+		return false
+	}
+
+	// move the country code and the check digits to the end, convert letters to
+	// numbers (A=10...Z=35) and compute the remainder digit by digit
+	remainder := 0
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A'+10)) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+func isUpperASCII(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+func isDigitASCII(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isPhoneValid(match string) bool {
+	n := len(digits(match))
+	return n >= 8 && n <= 15
+}
+
+// isUSSSNValid rejects the area, group and serial numbers never assigned.
+func isUSSSNValid(match string) bool {
+	area, group, serial := match[0:3], match[4:6], match[7:11]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
@@ -3,6 +3,8 @@ package slogformatter
 import (
 	"context"
 	"log/slog"
+	"net"
+	"net/netip"
 	"sync/atomic"
 	"testing"
 
@@ -23,7 +25,7 @@ func TestIPAddressFormatter(t *testing.T) {
 				Handle: func(ctx context.Context, record slog.Record) error {
 					record.Attrs(func(attr slog.Attr) bool {
 						if attr.Key == "ip" {
-							is.Equal("*******", attr.Value.String())
+							is.Equal("192.168.1.0", attr.Value.String())
 							atomic.AddInt32(&checked, 1)
 						}
 						return true
@@ -38,6 +40,68 @@ func TestIPAddressFormatter(t *testing.T) {
 	is.Equal(int32(1), atomic.LoadInt32(&checked))
 }
 
+func TestIPAddressFormatter_Types(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := IPAddressFormatter("ip")
+
+	tests := []struct {
+		value    slog.Value
+		expected string
+	}{
+		{slog.StringValue("192.168.1.42"), "192.168.1.0"},
+		{slog.StringValue(" 192.168.1.42 "), "192.168.1.0"},
+		{slog.StringValue("::ffff:192.168.1.42"), "192.168.1.0"},
+		{slog.StringValue("2001:db8:85a3:1234::8a2e:370:7334"), "2001:db8:85a3::"},
+		{slog.StringValue("fe80::1%eth0"), "fe80::"},
+		{slog.StringValue("[2001:db8:85a3::1]"), "2001:db8:85a3::"},
+		{slog.StringValue("192.168.1.42:8080"), "192.168.1.0:8080"},
+		{slog.StringValue("[2001:db8:85a3::1]:443"), "[2001:db8:85a3::]:443"},
+		{slog.StringValue("203.0.113.7, 10.0.0.1,2001:db8::1"), "203.0.113.0, 10.0.0.0, 2001:db8::"},
+		{slog.StringValue("203.0.113.7, unknown"), "203.0.113.0, *******"},
+		{slog.StringValue("bd57ffbd-8858-4cc4-a93b-426cef16de61"), "*******"},
+		{slog.StringValue(""), "*******"},
+		{slog.AnyValue(net.ParseIP("192.168.1.42")), "192.168.1.0"},
+		{slog.AnyValue(net.IPv4(192, 168, 1, 42).To4()), "192.168.1.0"},
+		{slog.AnyValue(net.IP{1, 2}), "*******"},
+		{slog.AnyValue(netip.MustParseAddr("2001:db8:85a3::1")), "2001:db8:85a3::"},
+		{slog.AnyValue(netip.Addr{}), "*******"},
+		{slog.AnyValue(netip.MustParseAddrPort("192.168.1.42:80")), "192.168.1.0:80"},
+		{slog.IntValue(42), "*******"},
+		{slog.GroupValue(slog.String("v4", "192.168.1.42")), "*******"},
+	}
+
+	for _, test := range tests {
+		v, ok := formatter(nil, slog.Attr{Key: "ip", Value: test.value})
+		is.True(ok)
+		is.Equal(test.expected, v.String(), test.value.String())
+	}
+}
+
+func TestIPAddressFormatterWithOptions(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := IPAddressFormatterWithOptions("ip", IPAddressFormatterOptions{IPv4PrefixLength: 16, IPv6PrefixLength: 64})
+
+	v, _ := formatter(nil, slog.String("ip", "192.168.1.42"))
+	is.Equal("192.168.0.0", v.String())
+	v, _ = formatter(nil, slog.String("ip", "2001:db8:85a3:1234:5678::1"))
+	is.Equal("2001:db8:85a3:1234::", v.String())
+
+	formatter = IPAddressFormatterWithOptions("ip", IPAddressFormatterOptions{IPv4PrefixLength: 32, IPv6PrefixLength: 128})
+	v, _ = formatter(nil, slog.String("ip", "192.168.1.42"))
+	is.Equal("192.168.1.42", v.String())
+
+	is.PanicsWithValue("slog-formatter: IPv4 prefix length must be between 1 and 32", func() {
+		IPAddressFormatterWithOptions("ip", IPAddressFormatterOptions{IPv4PrefixLength: 33})
+	})
+	is.PanicsWithValue("slog-formatter: IPv6 prefix length must be between 1 and 128", func() {
+		IPAddressFormatterWithOptions("ip", IPAddressFormatterOptions{IPv6PrefixLength: -1})
+	})
+}
+
 func TestIPAddressFormatter_NestedGroup(t *testing.T) {
 	t.Parallel()
 	is := assert.New(t)
@@ -53,7 +117,7 @@ func TestIPAddressFormatter_NestedGroup(t *testing.T) {
 						if attr.Key == "ctx" && attr.Value.Kind() == slog.KindGroup {
 							for _, a := range attr.Value.Group() {
 								if a.Key == "ip" {
-									is.Equal("*******", a.Value.String())
+									is.Equal("10.0.0.0", a.Value.String())
 									atomic.AddInt32(&checked, 1)
 								}
 							}
@@ -422,3 +486,199 @@ func TestPIIFormatter_ExactBoundary6Chars(t *testing.T) {
 	logger.Info("test", slog.Group("data", slog.String("val", "abcdef")))
 	is.Equal(int32(1), atomic.LoadInt32(&checked))
 }
+
+func TestPIIStrategies(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal("*******", PIIStrategyMask()(slog.StringValue("foobar@example.com")).String())
+	is.Equal("*******", PIIStrategyMask()(slog.IntValue(42)).String())
+
+	is.Equal("foo*******", PIIStrategyKeepPrefix(3)(slog.StringValue("foobar")).String())
+	is.Equal("Émi*******", PIIStrategyKeepPrefix(3)(slog.StringValue("Émilie")).String())
+	is.Equal("*******", PIIStrategyKeepPrefix(3)(slog.StringValue("foo")).String())
+	is.Equal("*******", PIIStrategyKeepPrefix(3)(slog.IntValue(123456)).String())
+
+	is.Equal("*******89", PIIStrategyKeepSuffix(2)(slog.StringValue("+33612345689")).String())
+	is.Equal("*******", PIIStrategyKeepSuffix(2)(slog.StringValue("ab")).String())
+	is.Equal("*******", PIIStrategyKeepSuffix(2)(slog.IntValue(123456)).String())
+
+	is.Equal("****", PIIStrategyFixedMask(4)(slog.StringValue("a very long secret")).String())
+	is.Equal("******", PIIStrategyPreserveLength()(slog.StringValue("Émilie")).String())
+	is.Equal("***", PIIStrategyPreserveLength()(slog.IntValue(123)).String())
+
+	is.Equal("c3ab8ff13720e8ad9047dd39466b3c8974e592c2fa383d4a3960714caef0c4f2", PIIStrategyHash()(slog.StringValue("foobar")).String())
+	is.Nil(PIIStrategyNull()(slog.StringValue("foobar")).Any())
+
+	is.Equal("foob*******", PIIStrategyDefault()(slog.StringValue("foobar")).String())
+	is.Equal("*******", PIIStrategyDefault()(slog.StringValue("foo")).String())
+}
+
+func TestPIIFormatterWithOptions(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := PIIFormatterWithOptions("user", PIIFormatterOptions{
+		Strategy: PIIStrategyMask(),
+		Rules: []PIIRule{
+			{Pattern: "EMAIL", Strategy: PIIStrategyHash()},
+			{Pattern: "*phone*", Strategy: PIIStrategyKeepSuffix(2)},
+			{Pattern: "address", Strategy: PIIStrategyNull()},
+			{Pattern: "zip", Strategy: PIIStrategyPreserveLength()},
+		},
+	})
+
+	v, ok := formatter(nil, slog.Group(
+		"user",
+		slog.String("id", "42"),
+		slog.String("email", "foobar"),
+		slog.String("mobile_phone", "+33612345689"),
+		slog.String("name", "John Doe"),
+		slog.Group("address",
+			slog.String("street", "1st street"),
+			slog.Int("zip", 12345),
+		),
+	))
+	is.True(ok)
+
+	attrs := map[string]slog.Value{}
+	for _, attr := range v.Group() {
+		attrs[attr.Key] = attr.Value
+	}
+
+	is.Equal("42", attrs["id"].String())
+	is.Equal("c3ab8ff13720e8ad9047dd39466b3c8974e592c2fa383d4a3960714caef0c4f2", attrs["email"].String())
+	is.Equal("*******89", attrs["mobile_phone"].String())
+	is.Equal("*******", attrs["name"].String())
+
+	address := attrs["address"].Group()
+	is.Len(address, 2)
+	is.Nil(address[0].Value.Any())
+	is.Equal("*****", address[1].Value.String())
+
+	is.PanicsWithValue("slog-formatter: invalid PII pattern: [", func() {
+		PIIFormatterWithOptions("user", PIIFormatterOptions{Rules: []PIIRule{{Pattern: "[", Strategy: PIIStrategyMask()}}})
+	})
+	is.PanicsWithValue("slog-formatter: missing PII rule strategy: email", func() {
+		PIIFormatterWithOptions("user", PIIFormatterOptions{Rules: []PIIRule{{Pattern: "email"}}})
+	})
+	is.PanicsWithValue("slog-formatter: PII strategy length must not be negative", func() {
+		PIIStrategyKeepPrefix(-1)
+	})
+	is.PanicsWithValue("slog-formatter: PII strategy length must not be negative", func() {
+		PIIStrategyKeepSuffix(-1)
+	})
+	is.PanicsWithValue("slog-formatter: PII strategy length must not be negative", func() {
+		PIIStrategyFixedMask(-1)
+	})
+	is.NotPanics(func() {
+		is.Equal("*******", PIIStrategyKeepPrefix(0)(slog.StringValue("")).String())
+		is.Equal("", PIIStrategyFixedMask(0)(slog.StringValue("secret")).String())
+	})
+}
+
+func TestPIIStrategies_UTF8(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal("Émil*******", PIIStrategyDefault()(slog.StringValue("Émilie")).String())
+	is.Equal("東京都千*******", PIIStrategyDefault()(slog.StringValue("東京都千代田区")).String())
+	is.Equal("*******", PIIStrategyDefault()(slog.StringValue("Zoë €")).String())
+	// "e" followed by a combining acute accent is a single character
+	is.Equal("René*******", PIIStrategyDefault()(slog.StringValue("René Dupont")).String())
+	is.Equal("👩‍👩‍👧a🇫🇷👍🏽*******", PIIStrategyDefault()(slog.StringValue("👩‍👩‍👧a🇫🇷👍🏽bc")).String())
+	is.Equal("�abc*******", PIIStrategyDefault()(slog.StringValue("\xffabcdef")).String())
+
+	is.Equal("*******区", PIIStrategyKeepSuffix(1)(slog.StringValue("東京都千代田区")).String())
+	is.Equal("*******🇫🇷", PIIStrategyKeepSuffix(1)(slog.StringValue("abc🇫🇷")).String())
+	is.Equal("***", PIIStrategyPreserveLength()(slog.StringValue("é🇫🇷👍🏽")).String())
+}
+
+func TestGraphemeBoundaries(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal([]int{0}, graphemeBoundaries(""))
+	is.Equal([]int{0, 1, 2}, graphemeBoundaries("ab"))
+	is.Equal([]int{0, 2, 3}, graphemeBoundaries("Éa"))
+	is.Equal([]int{0, 3, 4}, graphemeBoundaries("éa"))
+	is.Equal([]int{0, 8, 16}, graphemeBoundaries("🇫🇷🇯🇵"))
+	is.Equal([]int{0, 1, 2}, graphemeBoundaries("\xff\xfe"))
+}
+
+func TestPIIFormatterWithOptions_Preserve(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	format := func(formatter Formatter, attrs ...slog.Attr) map[string]string {
+		v, ok := formatter(nil, slog.Attr{Key: "user", Value: slog.GroupValue(attrs...)})
+		is.True(ok)
+
+		output := map[string]string{}
+		for _, attr := range v.Group() {
+			output[attr.Key] = attr.Value.String()
+		}
+		return output
+	}
+
+	attrs := []slog.Attr{
+		slog.String("id", "1"),
+		slog.String("userId", "2"),
+		slog.String("accountID", "3"),
+		slog.String("order-id", "4"),
+		slog.String("uuid", "5"),
+		slog.String("national_id", "1234567890"),
+		slog.String("taxId", "1234567890"),
+		slog.String("paid", "yes"),
+		slog.String("country", "France"),
+		slog.String("idempotency_key", "abcdef"),
+	}
+
+	is.Equal(map[string]string{
+		"id":              "1",
+		"userId":          "2",
+		"accountID":       "3",
+		"order-id":        "4",
+		"uuid":            "5",
+		"national_id":     "1234*******",
+		"taxId":           "1234*******",
+		"paid":            "*******",
+		"country":         "Fran*******",
+		"idempotency_key": "abcd*******",
+	}, format(PIIFormatter("user"), attrs...))
+
+	is.Equal(map[string]string{
+		"id":              "1",
+		"userId":          "2",
+		"accountID":       "3",
+		"order-id":        "4",
+		"uuid":            "*******",
+		"national_id":     "1234567890",
+		"taxId":           "1234*******",
+		"paid":            "*******",
+		"country":         "France",
+		"idempotency_key": "abcdef",
+	}, format(PIIFormatterWithOptions("user", PIIFormatterOptions{
+		Preserve: []string{"id", "*_id", "*_key", "country"},
+		Mask:     []string{"tax_id"},
+	}), attrs...))
+
+	is.Equal("*******", format(PIIFormatterWithOptions("user", PIIFormatterOptions{Preserve: []string{}}), slog.String("id", "1"))["id"])
+
+	is.PanicsWithValue("slog-formatter: invalid PII pattern: [", func() {
+		PIIFormatterWithOptions("user", PIIFormatterOptions{Mask: []string{"["}})
+	})
+}
+
+func TestSnakeCase(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal("user_id", snakeCase("userId"))
+	is.Equal("user_id", snakeCase("userID"))
+	is.Equal("user_id", snakeCase("user-id"))
+	is.Equal("http_server_id", snakeCase("HTTPServerID"))
+	is.Equal("order2_id", snakeCase("order2Id"))
+	is.Equal("id", snakeCase("ID"))
+	is.Equal("paid", snakeCase("paid"))
+}
@@ -0,0 +1,107 @@
+package slogformatter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"log/slog"
+)
+
+// PseudonymizeOptions configures the pseudonymization of values.
+type PseudonymizeOptions struct {
+	// Key is the HMAC-SHA256 secret. It must be at least 32 bytes long.
+	Key []byte
+	// KeyID identifies Key in the tokens ("psn_<KeyID>_<hash>"), so that tokens
+	// created before a key rotation can be told apart. It may only contain
+	// letters, digits and "-". Optional.
+	KeyID string
+	// Length is the number of hex characters of the token hash, between 8 and 64.
+	// Default: 16 (64 bits).
+	Length int
+	// Audit counts the attributes pseudonymized by PseudonymizeFormatter under the
+	// "pseudonymize" rule. Values pseudonymized by PIIStrategyPseudonymize are
+	// counted by PIIFormatterOptions.Audit. Optional.
+	Audit *RedactionAudit
+}
+
+type pseudonymizer struct {
+	key    []byte
+	prefix string
+	length int
+}
+
+func newPseudonymizer(opts PseudonymizeOptions) pseudonymizer {
+	if len(opts.Key) < 32 {
+		panic("slog-formatter: pseudonymization key must be at least 32 bytes long")
+	}
+	if !isKeyID(opts.KeyID) {
+		panic("slog-formatter: invalid pseudonymization key ID: " + opts.KeyID)
+	}
+	if opts.Length == 0 {
+		opts.Length = 16
+	}
+	if opts.Length < 8 || opts.Length > 64 {
+		panic("slog-formatter: pseudonymization length must be between 8 and 64")
+	}
+
+	prefix := "psn_"
+	if opts.KeyID != "" {
+		prefix += opts.KeyID + "_"
+	}
+
+	return pseudonymizer{
+		key:    opts.Key,
+		prefix: prefix,
+		length: opts.Length,
+	}
+}
+
+func (p pseudonymizer) token(value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(value))
+	return p.prefix + hex.EncodeToString(mac.Sum(nil))[:p.length]
+}
+
+// Pseudonymize returns the token of a value, such as "psn_k1_3f9a0c2b7d4e8f61".
+// Equal values give equal tokens with the same key, so that log entries can be
+// correlated without storing the value. It is useful to search the logs of a user.
+//
+// It panics when the options are invalid.
+func Pseudonymize(value string, opts PseudonymizeOptions) string {
+	return newPseudonymizer(opts).token(value)
+}
+
+// PIIStrategyPseudonymize replaces values by their HMAC-SHA256 token. Non-string
+// values are pseudonymized from their string representation.
+//
+// It panics when the options are invalid.
+func PIIStrategyPseudonymize(opts PseudonymizeOptions) PIIStrategy {
+	p := newPseudonymizer(opts)
+	return func(v slog.Value) slog.Value {
+		return slog.StringValue(p.token(v.String()))
+	}
+}
+
+// PseudonymizeFormatter replaces any value under provided key by a keyed
+// HMAC-SHA256 token. Nested values of groups are pseudonymized one by one.
+//
+// Example:
+//
+//	"email": "foobar@example.com"
+//
+// passed to PseudonymizeFormatter("email", PseudonymizeOptions{Key: secret, KeyID: "k1"}),
+// will be transformed into:
+//
+//	"email": "psn_k1_3f9a0c2b7d4e8f61"
+//
+// It panics when the options are invalid.
+func PseudonymizeFormatter(key string, opts PseudonymizeOptions) Formatter {
+	p := newPseudonymizer(opts)
+	return formatByKeyPath(key, func(groups []string, key string, v slog.Value) slog.Value {
+		if opts.Audit != nil {
+			opts.Audit.add("pseudonymize", joinPath(groups, key))
+		}
+		return mapLeaves(v, p.token)
+	})
+}
@@ -0,0 +1,64 @@
+package slogformatter
+
+import (
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPseudonymizeKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestPseudonymize(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	token := Pseudonymize("foobar@example.com", PseudonymizeOptions{Key: testPseudonymizeKey})
+	is.Regexp(regexp.MustCompile(`^psn_[0-9a-f]{16}$`), token)
+	is.Equal(token, Pseudonymize("foobar@example.com", PseudonymizeOptions{Key: testPseudonymizeKey}))
+	is.NotEqual(token, Pseudonymize("foobaz@example.com", PseudonymizeOptions{Key: testPseudonymizeKey}))
+
+	// key rotation
+	rotated := Pseudonymize("foobar@example.com", PseudonymizeOptions{Key: []byte("fedcba9876543210fedcba9876543210"), KeyID: "k2"})
+	is.Regexp(regexp.MustCompile(`^psn_k2_[0-9a-f]{16}$`), rotated)
+	is.NotEqual(token[4:], rotated[7:])
+
+	is.Len(Pseudonymize("foobar", PseudonymizeOptions{Key: testPseudonymizeKey, Length: 64}), 4+64)
+
+	is.PanicsWithValue("slog-formatter: pseudonymization key must be at least 32 bytes long", func() {
+		Pseudonymize("foobar", PseudonymizeOptions{Key: []byte("short")})
+	})
+	is.PanicsWithValue("slog-formatter: invalid pseudonymization key ID: k_1", func() {
+		Pseudonymize("foobar", PseudonymizeOptions{Key: testPseudonymizeKey, KeyID: "k_1"})
+	})
+	is.PanicsWithValue("slog-formatter: pseudonymization length must be between 8 and 64", func() {
+		Pseudonymize("foobar", PseudonymizeOptions{Key: testPseudonymizeKey, Length: 4})
+	})
+}
+
+func TestPseudonymizeFormatter(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	opts := PseudonymizeOptions{Key: testPseudonymizeKey, KeyID: "k1"}
+	formatter := PseudonymizeFormatter("email", opts)
+
+	v, ok := formatter(nil, slog.Group("user", slog.String("email", "foobar@example.com"), slog.String("name", "John")))
+	is.True(ok)
+	is.Equal(Pseudonymize("foobar@example.com", opts), v.Group()[0].Value.String())
+	is.Equal("John", v.Group()[1].Value.String())
+
+	_, ok = formatter(nil, slog.String("name", "John"))
+	is.False(ok)
+
+	// as a PIIFormatter strategy
+	formatter = PIIFormatterWithOptions("user", PIIFormatterOptions{
+		Rules: []PIIRule{{Pattern: "email", Strategy: PIIStrategyPseudonymize(opts)}},
+	})
+
+	v, ok = formatter(nil, slog.Group("user", slog.String("id", "42"), slog.String("email", "foobar@example.com")))
+	is.True(ok)
+	is.Equal("42", v.Group()[0].Value.String())
+	is.Equal(Pseudonymize("foobar@example.com", opts), v.Group()[1].Value.String())
+}
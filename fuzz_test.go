@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	slogmock "github.com/samber/slog-mock"
 )
@@ -97,7 +98,12 @@ func FuzzPIIFormatter(f *testing.F) {
 				slogmock.Option{
 					Handle: func(ctx context.Context, record slog.Record) error {
 						record.Attrs(func(attr slog.Attr) bool {
-							_ = attr.Value.String()
+							// preserved IDs are emitted as is
+							for _, a := range attr.Value.Group() {
+								if output := a.Value.String(); output != value && !utf8.ValidString(output) {
+									t.Errorf("invalid UTF-8 output for %q: %q", value, output)
+								}
+							}
 							return true
 						})
 						return nil
@@ -110,6 +116,58 @@ func FuzzPIIFormatter(f *testing.F) {
 	})
 }
 
+func FuzzPIIStrategies(f *testing.F) {
+	f.Add("Émilie", 2)
+	f.Add("東京都千代田区", 3)
+	f.Add("e\u0301e\u0301e\u0301", 1)
+	f.Add("👩‍👩‍👧🇫🇷🇯🇵👍🏽", 2)
+	f.Add("\xff\xfeabc", 1)
+	f.Add("", 0)
+
+	f.Fuzz(func(t *testing.T, value string, n int) {
+		if n < 0 || n > 64 {
+			return
+		}
+
+		strategies := []PIIStrategy{
+			PIIStrategyDefault(),
+			PIIStrategyMask(),
+			PIIStrategyKeepPrefix(n),
+			PIIStrategyKeepSuffix(n),
+			PIIStrategyFixedMask(n),
+			PIIStrategyPreserveLength(),
+			PIIStrategyHash(),
+		}
+
+		for _, strategy := range strategies {
+			output := strategy(slog.StringValue(value)).String()
+			if !utf8.ValidString(output) {
+				t.Errorf("invalid UTF-8 output for %q: %q", value, output)
+			}
+		}
+	})
+}
+
+func FuzzPIIScannerFormatter(f *testing.F) {
+	f.Add("contact émilie@exemple.fr or +33 6 12 34 56 78")
+	f.Add("card 4111 1111 1111 1111 from 192.168.1.1")
+	f.Add("東京 foo@example.com 👍🏽")
+	f.Add("")
+
+	scanner := newPIIScanner(PIIScannerOptions{})
+
+	f.Fuzz(func(t *testing.T, value string) {
+		if !utf8.ValidString(value) {
+			return
+		}
+
+		output := scanner.scan("", value)
+		if !utf8.ValidString(output) {
+			t.Errorf("invalid UTF-8 output for %q: %q", value, output)
+		}
+	})
+}
+
 func FuzzFlattenAttrs(f *testing.F) {
 	f.Add("key1", "val1", "key2", "val2", "group")
 	f.Add("", "", "", "", "")
@@ -239,6 +297,8 @@ func FuzzIPAddressFormatter(f *testing.F) {
 	f.Add("ip", "192.168.1.1")
 	f.Add("ip", "::1")
 	f.Add("ip", "not-an-ip")
+	f.Add("ip", "[2001:db8::1]:443")
+	f.Add("ip", "203.0.113.7, 10.0.0.1")
 	f.Add("ip", "")
 	f.Add("other", "10.0.0.1")
 
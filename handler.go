@@ -9,18 +9,35 @@ import (
 var _ slog.Handler = (*FormatterHandler)(nil)
 
 type FormatterHandler struct {
-	groups     []string
-	formatters []Formatter
-	handler    slog.Handler
+	groups          []string
+	formatters      []Formatter
+	messageRedactor PIIRedactor
+	handler         slog.Handler
+}
+
+// FormatterHandlerOptions configures NewFormatterHandlerWithOptions.
+type FormatterHandlerOptions struct {
+	// Formatters are applied to the attributes, in order.
+	Formatters []Formatter
+	// MessageRedactor replaces the PII found in record messages, such as
+	// "user foobar@example.com not found". Optional. See PIIScannerRedactor.
+	MessageRedactor PIIRedactor
 }
 
 // NewFormatterHandler returns a slog.Handler that applies formatters to.
 func NewFormatterHandler(formatters ...Formatter) func(slog.Handler) slog.Handler {
+	return NewFormatterHandlerWithOptions(FormatterHandlerOptions{Formatters: formatters})
+}
+
+// NewFormatterHandlerWithOptions returns a slog.Handler that applies formatters to
+// attributes and, optionally, redacts record messages.
+func NewFormatterHandlerWithOptions(opts FormatterHandlerOptions) func(slog.Handler) slog.Handler {
 	return func(handler slog.Handler) slog.Handler {
 		return &FormatterHandler{
-			groups:     []string{},
-			formatters: formatters,
-			handler:    handler,
+			groups:          []string{},
+			formatters:      opts.Formatters,
+			messageRedactor: opts.MessageRedactor,
+			handler:         handler,
 		}
 	}
 }
@@ -32,7 +49,12 @@ func (h *FormatterHandler) Enabled(ctx context.Context, l slog.Level) bool {
 
 // Handle implements slog.Handler.
 func (h *FormatterHandler) Handle(ctx context.Context, r slog.Record) error {
-	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	message := r.Message
+	if h.messageRedactor != nil {
+		message = h.messageRedactor(slog.MessageKey, message)
+	}
+
+	r2 := slog.NewRecord(r.Time, r.Level, message, r.PC)
 	r.Attrs(func(attr slog.Attr) bool {
 		r2.AddAttrs(h.transformAttr(h.groups, attr))
 		return true
@@ -46,9 +68,10 @@ func (h *FormatterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	attrs = h.transformAttrs(h.groups, attrs)
 
 	return &FormatterHandler{
-		groups:     h.groups,
-		formatters: h.formatters,
-		handler:    h.handler.WithAttrs(attrs),
+		groups:          h.groups,
+		formatters:      h.formatters,
+		messageRedactor: h.messageRedactor,
+		handler:         h.handler.WithAttrs(attrs),
 	}
 }
 
@@ -64,9 +87,10 @@ func (h *FormatterHandler) WithGroup(name string) slog.Handler {
 	newGroups[len(h.groups)] = name
 
 	return &FormatterHandler{
-		groups:     newGroups,
-		formatters: h.formatters,
-		handler:    h.handler.WithGroup(name),
+		groups:          newGroups,
+		formatters:      h.formatters,
+		messageRedactor: h.messageRedactor,
+		handler:         h.handler.WithGroup(name),
 	}
 }
 
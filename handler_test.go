@@ -371,3 +371,35 @@ func TestFormatterHandler_NestedLogValuer(t *testing.T) {
 	logger.Info("test", slog.Any("nested", nestedLogValuer{inner: testLogValuer{val: "deep_value"}}))
 	is.Equal(int32(1), atomic.LoadInt32(&checked))
 }
+
+func TestFormatterHandler_MessageRedactor(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var checked int32
+	audit := NewRedactionAudit()
+	handler := NewFormatterHandlerWithOptions(FormatterHandlerOptions{
+		Formatters:      []Formatter{PIIFormatter("user")},
+		MessageRedactor: PIIScannerRedactor(PIIScannerOptions{Audit: audit}),
+	})
+
+	logger := slog.New(
+		handler(
+			slogmock.Option{
+				Handle: func(ctx context.Context, record slog.Record) error {
+					is.Equal("user ******* not found", record.Message)
+					record.Attrs(func(attr slog.Attr) bool {
+						is.Equal("[email=foob*******]", attr.Value.String())
+						return true
+					})
+					atomic.AddInt32(&checked, 1)
+					return nil
+				},
+			}.NewMockHandler(),
+		),
+	)
+
+	logger.Info("user foobar@example.com not found", slog.Group("user", slog.String("email", "foobar@example.com")))
+	is.Equal(int32(1), atomic.LoadInt32(&checked))
+	is.Equal([]RedactionCount{{Rule: "pii_scanner:email", Path: "msg", Count: 1}}, audit.Stats().Counts)
+}
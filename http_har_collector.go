@@ -0,0 +1,246 @@
+package slogformatter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"log/slog"
+)
+
+// HAREntry is an entry of a HAR document: a request and its response.
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARTimings holds the durations of a HAR entry, in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARCreator identifies the application that produced a HAR document.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HARLog is the root object of a HAR document.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARDocument is an HTTP Archive 1.2 document, loadable in browser devtools.
+// See http://www.softwareishard.com/blog/har-12-spec/.
+type HARDocument struct {
+	Log HARLog `json:"log"`
+}
+
+// HARCollectorOptions configures NewHARCollector.
+type HARCollectorOptions struct {
+	// Creator identifies the application. Default: {Name: "slog-formatter", Version: "1"}.
+	Creator HARCreator
+	// Request configures the rendering of *http.Request attributes. Schema is ignored.
+	Request HTTPRequestFormatterOptions
+	// Response configures the rendering of *http.Response attributes. Schema is ignored.
+	Response HTTPResponseFormatterOptions
+}
+
+// HARCollector is a slog.Handler collecting HTTP exchanges into a HAR document.
+//
+// Every record holding a request produces an entry. Requests and responses are
+// found in attributes of type *http.Request and *http.Response, or HARRequest and
+// HARResponse (see HTTPSchemaHAR). A "latency" duration sets the entry time, and
+// an "error" attribute sets the entry comment. Records without a request are ignored.
+//
+// Example:
+//
+//	collector := slogformatter.NewHARCollector(slogformatter.HARCollectorOptions{})
+//	logger := slog.New(slogmulti.Fanout(handler, collector))
+//
+//	client := &http.Client{
+//	    Transport: slogformatter.NewHTTPTransport(logger, slogformatter.HTTPTransportOptions{
+//	        Request:  slogformatter.HTTPRequestFormatterOptions{Schema: slogformatter.HTTPSchemaHAR},
+//	        Response: slogformatter.HTTPResponseFormatterOptions{Schema: slogformatter.HTTPSchemaHAR},
+//	    }),
+//	}
+//
+//	// ...
+//	collector.WriteTo(file)
+type HARCollector struct {
+	store     *harStore
+	requests  httpRequestFormatter
+	responses httpResponseFormatter
+	attrs     []slog.Attr
+}
+
+type harStore struct {
+	mu      sync.Mutex
+	creator HARCreator
+	entries []HAREntry
+}
+
+var _ slog.Handler = (*HARCollector)(nil)
+
+// NewHARCollector returns an empty HARCollector.
+func NewHARCollector(opts HARCollectorOptions) *HARCollector {
+	if opts.Creator.Name == "" {
+		opts.Creator = HARCreator{Name: "slog-formatter", Version: "1"}
+	}
+
+	return &HARCollector{
+		store:     &harStore{creator: opts.Creator},
+		requests:  newHTTPRequestFormatter(opts.Request),
+		responses: newHTTPResponseFormatter(opts.Response),
+	}
+}
+
+// Enabled implements slog.Handler.
+func (c *HARCollector) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements slog.Handler.
+func (c *HARCollector) Handle(_ context.Context, record slog.Record) error {
+	var entry harEntryBuilder
+
+	for _, attr := range c.attrs {
+		c.visit(&entry, attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		c.visit(&entry, attr)
+		return true
+	})
+
+	if entry.request == nil {
+		return nil
+	}
+
+	if entry.response == nil {
+		entry.response = &HARResponse{
+			Cookies:     []HARCookie{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	}
+
+	latency := float64(entry.latency) / float64(time.Millisecond)
+	started := record.Time
+	if started.IsZero() {
+		started = time.Now()
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	c.store.entries = append(c.store.entries, HAREntry{
+		StartedDateTime: started.Add(-entry.latency).Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            latency,
+		Request:         *entry.request,
+		Response:        *entry.response,
+		Timings:         HARTimings{Wait: latency},
+		Comment:         entry.comment,
+	})
+
+	return nil
+}
+
+type harEntryBuilder struct {
+	request  *HARRequest
+	response *HARResponse
+	latency  time.Duration
+	comment  string
+}
+
+// visit walks the attribute, including nested groups.
+func (c *HARCollector) visit(entry *harEntryBuilder, attr slog.Attr) {
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		for _, child := range value.Group() {
+			c.visit(entry, child)
+		}
+	case slog.KindDuration:
+		if attr.Key == "latency" {
+			entry.latency = value.Duration()
+		}
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case *http.Request:
+			request := c.requests.harRequest(v)
+			entry.request = &request
+		case HARRequest:
+			entry.request = &v
+		case *http.Response:
+			response := c.responses.harResponse(v)
+			entry.response = &response
+		case HARResponse:
+			entry.response = &v
+		case error:
+			if attr.Key == "error" {
+				entry.comment = v.Error()
+			}
+		}
+	default:
+		if attr.Key == "error" {
+			entry.comment = value.String()
+		}
+	}
+}
+
+// WithAttrs implements slog.Handler.
+func (c *HARCollector) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &HARCollector{
+		store:     c.store,
+		requests:  c.requests,
+		responses: c.responses,
+		attrs:     append(c.attrs[:len(c.attrs):len(c.attrs)], attrs...),
+	}
+}
+
+// WithGroup implements slog.Handler. Groups are ignored.
+func (c *HARCollector) WithGroup(string) slog.Handler {
+	return c
+}
+
+// Document returns a snapshot of the collected entries.
+func (c *HARCollector) Document() HARDocument {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	entries := make([]HAREntry, len(c.store.entries))
+	copy(entries, c.store.entries)
+
+	return HARDocument{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: c.store.creator,
+			Entries: entries,
+		},
+	}
+}
+
+// WriteTo writes the HAR document as JSON. It implements io.WriterTo.
+func (c *HARCollector) WriteTo(w io.Writer) (int64, error) {
+	output, err := json.MarshalIndent(c.Document(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(output)
+	return int64(n), err
+}
@@ -0,0 +1,164 @@
+package slogformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	slogmulti "github.com/samber/slog-multi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRequestFormatterWithOptions_HAR(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/login?next=/home&token=abcd", strings.NewReader("user=john&password=secret"))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer abcd")
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "abcd"})
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Schema: HTTPSchemaHAR,
+		Body:   HTTPBodyOptions{MaxSize: 1024},
+	})
+
+	v, ok := formatter(nil, slog.Any("request", req))
+	is.True(ok)
+
+	har, ok := v.Any().(HARRequest)
+	is.True(ok)
+	is.Equal("POST", har.Method)
+	is.Equal("https://example.com/login?next=/home&token=*******", har.URL)
+	is.Equal("HTTP/1.1", har.HTTPVersion)
+	is.Equal([]HARCookie{{Name: "session_id", Value: "*******"}, {Name: "theme", Value: "dark"}}, har.Cookies)
+	is.Equal([]HARNameValue{
+		{Name: "Authorization", Value: "*******"},
+		{Name: "Content-Type", Value: "application/x-www-form-urlencoded"},
+		{Name: "Cookie", Value: "*******"},
+	}, har.Headers)
+	is.Equal([]HARNameValue{{Name: "next", Value: "/home"}, {Name: "token", Value: "*******"}}, har.QueryString)
+	is.Equal(&HARPostData{MimeType: "application/x-www-form-urlencoded", Text: "password=*******&user=john"}, har.PostData)
+	is.Equal(int64(-1), har.HeadersSize)
+	is.Equal(int64(25), har.BodySize)
+
+	body, err := io.ReadAll(req.Body)
+	is.NoError(err)
+	is.Equal("user=john&password=secret", string(body))
+}
+
+func TestHTTPResponseFormatterWithOptions_HAR(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	res := &http.Response{
+		StatusCode:    http.StatusFound,
+		Proto:         "HTTP/2.0",
+		ContentLength: -1,
+		Header: http.Header{
+			"Location":     []string{"/home"},
+			"Content-Type": []string{"text/plain"},
+			"Set-Cookie":   []string{"session_id=abcd; Path=/; HttpOnly; Secure"},
+		},
+		Body: io.NopCloser(strings.NewReader("redirecting")),
+	}
+
+	formatter := HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{
+		Schema: HTTPSchemaHAR,
+		Body:   HTTPBodyOptions{MaxSize: 1024},
+	})
+
+	v, ok := formatter(nil, slog.Any("response", res))
+	is.True(ok)
+
+	har, ok := v.Any().(HARResponse)
+	is.True(ok)
+	is.Equal(302, har.Status)
+	is.Equal("Found", har.StatusText)
+	is.Equal("HTTP/2.0", har.HTTPVersion)
+	is.Equal([]HARCookie{{Name: "session_id", Value: "*******", Path: "/", HTTPOnly: true, Secure: true}}, har.Cookies)
+	is.Equal(HARContent{Size: 11, MimeType: "text/plain", Text: "redirecting"}, har.Content)
+	is.Equal("/home", har.RedirectURL)
+	is.Equal(int64(-1), har.BodySize)
+}
+
+func TestHARCollector(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"abcd","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	base := &http.Transport{}
+	defer base.CloseIdleConnections()
+
+	var buf bytes.Buffer
+	collector := NewHARCollector(HARCollectorOptions{})
+	logger := slog.New(slogmulti.Fanout(slog.NewJSONHandler(&buf, nil), collector))
+
+	client := &http.Client{
+		Transport: NewHTTPTransport(logger, HTTPTransportOptions{
+			Transport: base,
+			Request:   HTTPRequestFormatterOptions{Schema: HTTPSchemaHAR},
+			Response:  HTTPResponseFormatterOptions{Schema: HTTPSchemaHAR, Body: HTTPBodyOptions{MaxSize: 1024}},
+		}),
+	}
+
+	res, err := client.Get(server.URL + "/oauth/token")
+	is.NoError(err)
+	is.NoError(res.Body.Close())
+
+	// raw values are rendered by the collector
+	req, err := http.NewRequest(http.MethodDelete, "https://example.com/users/42", nil)
+	is.NoError(err)
+	logger.With(slog.Any("request", req)).Error("request failed", slog.Any("error", errors.New("boom")), slog.Duration("latency", 250*time.Millisecond))
+
+	// records without request are ignored
+	logger.Info("hello world")
+
+	var output bytes.Buffer
+	_, err = collector.WriteTo(&output)
+	is.NoError(err)
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal(output.Bytes(), &doc))
+
+	log, ok := doc["log"].(map[string]any)
+	is.True(ok)
+	is.Equal("1.2", log["version"])
+	is.Equal(map[string]any{"name": "slog-formatter", "version": "1"}, log["creator"])
+
+	entries, ok := log["entries"].([]any)
+	is.True(ok)
+	is.Len(entries, 2)
+
+	first := entries[0].(map[string]any)
+	is.Equal("GET", first["request"].(map[string]any)["method"])
+	is.Equal(server.URL+"/oauth/token", first["request"].(map[string]any)["url"])
+	is.Equal(float64(200), first["response"].(map[string]any)["status"])
+	is.Equal(`{"access_token":"*******","expires_in":3600}`, first["response"].(map[string]any)["content"].(map[string]any)["text"])
+	is.Contains(first, "cache")
+	is.Contains(first, "timings")
+	is.IsType("", first["startedDateTime"])
+
+	second := entries[1].(map[string]any)
+	is.Equal("DELETE", second["request"].(map[string]any)["method"])
+	is.Equal(float64(0), second["response"].(map[string]any)["status"])
+	is.Equal(float64(250), second["time"])
+	is.Equal("boom", second["comment"])
+
+	is.Len(collector.Document().Log.Entries, 2)
+	is.Contains(buf.String(), `"queryString":[]`)
+}
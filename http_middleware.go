@@ -0,0 +1,251 @@
+package slogformatter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"log/slog"
+)
+
+// HTTPMiddlewareOptions configures NewHTTPMiddleware.
+type HTTPMiddlewareOptions struct {
+	// Message is the message of the access log records. Default: "http request".
+	Message string
+	// Level returns the level of a record from the response status.
+	// Default: DefaultHTTPStatusLevel.
+	Level func(status int) slog.Level
+	// SkipPaths are URL path patterns that are not logged, such as "/healthz" or
+	// "/static/*". Patterns use the path.Match syntax. The middleware panics on
+	// invalid patterns. The request-scoped logger is still attached to the context.
+	SkipPaths []string
+	// RequestIDHeader is the request header holding the request ID. When present,
+	// the request ID is added as "request_id" to the request-scoped logger and to the
+	// access log. Default: "X-Request-Id".
+	RequestIDHeader string
+	// Request configures the "request" attribute.
+	Request HTTPRequestFormatterOptions
+	// Response configures the "response" attribute. Response.Body.MaxSize bytes of the
+	// written body are captured.
+	Response HTTPResponseFormatterOptions
+}
+
+// DefaultHTTPStatusLevel logs 5xx responses with slog.LevelError, 4xx responses
+// with slog.LevelWarn and other responses with slog.LevelInfo.
+func DefaultHTTPStatusLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type httpLoggerContextKey struct{}
+
+// HTTPLoggerFromContext returns the request-scoped logger attached by the
+// middleware returned by NewHTTPMiddleware, or slog.Default().
+func HTTPLoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(httpLoggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// NewHTTPMiddleware returns a net/http middleware producing one access log per
+// request, with "request", "response" and "latency" attributes. The response
+// status, the number of bytes written and the duration are captured by wrapping
+// the http.ResponseWriter.
+//
+// A request-scoped logger is attached to the request context: see HTTPLoggerFromContext.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+//	    slogformatter.HTTPLoggerFromContext(r.Context()).Info("listing users")
+//	})
+//
+//	handler := slogformatter.NewHTTPMiddleware(logger, slogformatter.HTTPMiddlewareOptions{
+//	    SkipPaths: []string{"/healthz"},
+//	})(mux)
+func NewHTTPMiddleware(logger *slog.Logger, opts HTTPMiddlewareOptions) func(http.Handler) http.Handler {
+	if opts.Message == "" {
+		opts.Message = "http request"
+	}
+	if opts.Level == nil {
+		opts.Level = DefaultHTTPStatusLevel
+	}
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = "X-Request-Id"
+	}
+	for _, pattern := range opts.SkipPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic("slog-formatter: invalid skip path pattern: " + pattern)
+		}
+	}
+
+	requests := newHTTPRequestFormatter(opts.Request)
+	responses := newHTTPResponseFormatter(opts.Response)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scoped := logger
+			if requestID := r.Header.Get(opts.RequestIDHeader); requestID != "" {
+				scoped = logger.With(slog.String("request_id", requestID))
+			}
+			r = r.WithContext(context.WithValue(r.Context(), httpLoggerContextKey{}, scoped))
+
+			if skipHTTPPath(opts.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+
+			// The request is formatted once served, since http.ServeMux sets the
+			// route pattern. Its body must be captured before the handler reads it.
+			body := peekHTTPBody(&r.Body, opts.Request.Body.MaxSize)
+
+			recorder := &httpResponseRecorder{
+				ResponseWriter: w,
+				maxBodySize:    opts.Response.Body.MaxSize,
+			}
+			next.ServeHTTP(recorder, r)
+
+			logged := r.WithContext(r.Context())
+			if body != nil {
+				logged.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			latency := time.Since(start)
+			status := recorder.statusCode()
+
+			res := &http.Response{
+				Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+				StatusCode:    status,
+				Proto:         r.Proto,
+				ProtoMajor:    r.ProtoMajor,
+				ProtoMinor:    r.ProtoMinor,
+				Header:        w.Header(),
+				ContentLength: recorder.bytes,
+				Body:          http.NoBody,
+				Request:       r,
+			}
+			if recorder.body.Len() > 0 {
+				res.Body = io.NopCloser(&recorder.body)
+			}
+
+			attrs := []slog.Attr{
+				{Key: "request", Value: requests.format(logged)},
+				{Key: "response", Value: responses.format(res)},
+				slog.Duration("latency", latency),
+			}
+
+			scoped.LogAttrs(r.Context(), opts.Level(status), opts.Message, attrs...)
+		})
+	}
+}
+
+// peekHTTPBody reads the first maxSize+1 bytes of a body, enough for the body
+// capture to detect truncation, and replaces the body with a replay reader. It
+// returns nil when body capture is disabled or when there is no body.
+func peekHTTPBody(body *io.ReadCloser, maxSize int64) []byte {
+	if maxSize <= 0 || *body == nil || *body == http.NoBody {
+		return nil
+	}
+
+	original := *body
+	buf, _ := io.ReadAll(io.LimitReader(original, maxSize+1))
+	*body = &replayReadCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf), original),
+		Closer: original,
+	}
+
+	return buf
+}
+
+func skipHTTPPath(patterns []string, urlPath string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, urlPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// httpResponseRecorder captures the status, the number of bytes written and the
+// first bytes of the body. Optional interfaces of the wrapped http.ResponseWriter
+// are reachable with http.ResponseController, and http.Flusher and http.Hijacker
+// are implemented for the handlers asserting them directly.
+type httpResponseRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	bytes       int64
+	maxBodySize int64
+	body        bytes.Buffer
+}
+
+func (r *httpResponseRecorder) WriteHeader(status int) {
+	// informational responses, such as 103 Early Hints, precede the final status
+	isInformational := status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+	if r.status == 0 && !isInformational {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *httpResponseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	// one extra byte flags truncated bodies
+	if remaining := r.maxBodySize + 1 - int64(r.body.Len()); r.maxBodySize > 0 && remaining > 0 {
+		r.body.Write(b[:min(int64(len(b)), remaining)])
+	}
+
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (r *httpResponseRecorder) Flush() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, for websocket upgraders. The response status
+// is then written by the handler on the connection: it is logged as 101 Switching
+// Protocols.
+func (r *httpResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap is used by http.ResponseController.
+func (r *httpResponseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *httpResponseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
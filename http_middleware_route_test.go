@@ -0,0 +1,53 @@
+//go:build go1.23
+
+package slogformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPMiddleware_Route(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var received string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	handler := NewHTTPMiddleware(logger, HTTPMiddlewareOptions{
+		Request: HTTPRequestFormatterOptions{
+			WithRoute: true,
+			Body:      HTTPBodyOptions{MaxSize: 1024},
+		},
+	})(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/42", strings.NewReader(`{"name":"john"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	is.Equal(`{"name":"john"}`, received)
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &doc))
+
+	request, ok := doc["request"].(map[string]any)
+	is.True(ok)
+	is.Equal("POST /users/{id}", request["route"])
+	is.Equal(map[string]any{"name": "john"}, request["body"].(map[string]any)["content"])
+}
@@ -0,0 +1,195 @@
+package slogformatter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPMiddleware(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := NewHTTPMiddleware(logger, HTTPMiddlewareOptions{
+		Response: HTTPResponseFormatterOptions{Body: HTTPBodyOptions{MaxSize: 5}},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		HTTPLoggerFromContext(r.Context()).Info("listing users")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users?token=abcd", nil)
+	req.Header.Set("X-Request-Id", "req-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	is.Equal(http.StatusNotFound, rec.Code)
+	is.Equal("not found", rec.Body.String())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	is.Len(lines, 2)
+
+	var scoped map[string]any
+	is.NoError(json.Unmarshal([]byte(lines[0]), &scoped))
+	is.Equal("listing users", scoped["msg"])
+	is.Equal("req-42", scoped["request_id"])
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal([]byte(lines[1]), &doc))
+	is.Equal("WARN", doc["level"])
+	is.Equal("http request", doc["msg"])
+	is.Equal("req-42", doc["request_id"])
+	is.IsType(float64(0), doc["latency"])
+
+	request, ok := doc["request"].(map[string]any)
+	is.True(ok)
+	is.Equal("GET", request["method"])
+	is.Equal("/users?token=*******", request["url"].(map[string]any)["url"])
+
+	response, ok := doc["response"].(map[string]any)
+	is.True(ok)
+	is.Equal(float64(404), response["status"])
+	is.Equal("404 Not Found", response["status_text"])
+	is.Equal(float64(9), response["content_length"])
+	is.Equal(map[string]any{"content_type": "text/plain", "size": float64(5), "truncated": true, "content": "not f"}, response["body"])
+}
+
+func TestNewHTTPMiddleware_Levels(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal(slog.LevelInfo, DefaultHTTPStatusLevel(http.StatusOK))
+	is.Equal(slog.LevelInfo, DefaultHTTPStatusLevel(http.StatusFound))
+	is.Equal(slog.LevelWarn, DefaultHTTPStatusLevel(http.StatusBadRequest))
+	is.Equal(slog.LevelError, DefaultHTTPStatusLevel(http.StatusBadGateway))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := NewHTTPMiddleware(logger, HTTPMiddlewareOptions{
+		Level: func(status int) slog.Level { return slog.LevelInfo + slog.Level(status/100) },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &doc))
+	is.Equal("INFO+2", doc["level"])
+	is.Equal(float64(200), doc["response"].(map[string]any)["status"])
+	is.Equal(float64(2), doc["response"].(map[string]any)["content_length"])
+}
+
+func TestNewHTTPMiddleware_SkipPaths(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var scoped *slog.Logger
+	handler := NewHTTPMiddleware(logger, HTTPMiddlewareOptions{
+		SkipPaths: []string{"/healthz", "/static/*"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scoped = HTTPLoggerFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	is.Empty(buf.String())
+	is.Same(logger, scoped)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/static/js/app.js", nil))
+	is.Contains(buf.String(), `"status":204`)
+
+	is.Same(slog.Default(), HTTPLoggerFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()))
+	is.PanicsWithValue("slog-formatter: invalid skip path pattern: [", func() {
+		NewHTTPMiddleware(logger, HTTPMiddlewareOptions{SkipPaths: []string{"["}})
+	})
+}
+
+func TestNewHTTPMiddleware_Flush(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := NewHTTPMiddleware(logger, HTTPMiddlewareOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("chunk"))
+		is.NoError(http.NewResponseController(w).Flush())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	is.True(rec.Flushed)
+	is.Contains(buf.String(), `"status":200`)
+}
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestNewHTTPMiddleware_Hijack(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var err error
+	handler := NewHTTPMiddleware(logger, HTTPMiddlewareOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		is.True(ok)
+		_, _, err = hijacker.Hijack()
+	}))
+
+	rec := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	is.NoError(err)
+	is.True(rec.hijacked)
+	is.Contains(buf.String(), `"status":101`)
+
+	// not supported by the wrapped writer
+	buf.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws", nil))
+	is.ErrorIs(err, http.ErrNotSupported)
+	is.Contains(buf.String(), `"status":200`)
+}
+
+func TestNewHTTPMiddleware_InformationalStatus(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := NewHTTPMiddleware(logger, HTTPMiddlewareOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	is.Contains(buf.String(), `"status":201`)
+}
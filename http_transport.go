@@ -0,0 +1,202 @@
+package slogformatter
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"log/slog"
+)
+
+// HTTPTransportOptions configures NewHTTPTransport.
+type HTTPTransportOptions struct {
+	// Transport sends the requests. Default: http.DefaultTransport.
+	Transport http.RoundTripper
+	// Message is the message of the log records. Default: "http request".
+	Message string
+	// Level is the level of successful round trips. Failed round trips are logged
+	// with slog.LevelError. Default: slog.LevelInfo.
+	Level slog.Level
+	// Request configures the "request" attribute.
+	Request HTTPRequestFormatterOptions
+	// Response configures the "response" attribute.
+	Response HTTPResponseFormatterOptions
+	// WithTrace adds a "timings" group measured with net/http/httptrace:
+	// "dns", "connect", "tls_handshake", "first_byte" and "reused".
+	WithTrace bool
+}
+
+// NewHTTPTransport returns an http.RoundTripper logging every outgoing request
+// and its response with the given logger.
+//
+// Each round trip produces a single record with "request", "response" (or "error")
+// and "latency" attributes. Latency is measured until the response headers are received.
+//
+// Example:
+//
+//	client := &http.Client{
+//	    Transport: slogformatter.NewHTTPTransport(logger, slogformatter.HTTPTransportOptions{WithTrace: true}),
+//	}
+func NewHTTPTransport(logger *slog.Logger, opts HTTPTransportOptions) http.RoundTripper {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	if opts.Message == "" {
+		opts.Message = "http request"
+	}
+
+	return &httpTransport{
+		logger:    logger,
+		opts:      opts,
+		requests:  newHTTPRequestFormatter(opts.Request),
+		responses: newHTTPResponseFormatter(opts.Response),
+	}
+}
+
+type httpTransport struct {
+	logger    *slog.Logger
+	opts      HTTPTransportOptions
+	requests  httpRequestFormatter
+	responses httpResponseFormatter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.logger.Enabled(ctx, t.opts.Level) && !t.logger.Enabled(ctx, slog.LevelError) {
+		return t.opts.Transport.RoundTrip(req)
+	}
+
+	start := time.Now()
+
+	var timings *httpTimings
+	if t.opts.WithTrace {
+		timings = &httpTimings{start: start}
+		ctx = httptrace.WithClientTrace(ctx, timings.clientTrace())
+	}
+
+	// A RoundTripper must not modify the request: the body capture replaces
+	// the body of a shallow copy.
+	outgoing := req.WithContext(ctx)
+
+	attrs := []slog.Attr{
+		{Key: "request", Value: t.requests.format(outgoing)},
+	}
+
+	res, err := t.opts.Transport.RoundTrip(outgoing)
+	latency := time.Since(start)
+
+	level := t.opts.Level
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.Any("error", err))
+	} else {
+		res.Request = req
+		attrs = append(attrs, slog.Attr{Key: "response", Value: t.responses.format(res)})
+	}
+
+	attrs = append(attrs, slog.Duration("latency", latency))
+
+	if timings != nil {
+		attrs = append(attrs, slog.Attr{Key: "timings", Value: timings.value()})
+	}
+
+	t.logger.LogAttrs(req.Context(), level, t.opts.Message, attrs...)
+
+	return res, err
+}
+
+// httpTimings collects httptrace events. Hooks may be called concurrently
+// (eg: DNS resolution and dialing happen in other goroutines).
+type httpTimings struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dns          time.Duration
+	connectStart time.Time
+	connect      time.Duration
+	tlsStart     time.Time
+	tlsHandshake time.Duration
+	firstByte    time.Duration
+	reused       bool
+	gotConn      bool
+}
+
+func (t *httpTimings) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dns = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_ string, _ string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil && t.connect == 0 {
+				t.connect = time.Since(t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsHandshake = time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+			t.gotConn = true
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Since(t.start)
+		},
+	}
+}
+
+// value renders the measured phases. Phases that did not happen, such as DNS
+// resolution on a reused connection, are omitted.
+func (t *httpTimings) value() slog.Value {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attrs := []slog.Attr{}
+	if t.dns > 0 {
+		attrs = append(attrs, slog.Duration("dns", t.dns))
+	}
+	if t.connect > 0 {
+		attrs = append(attrs, slog.Duration("connect", t.connect))
+	}
+	if t.tlsHandshake > 0 {
+		attrs = append(attrs, slog.Duration("tls_handshake", t.tlsHandshake))
+	}
+	if t.firstByte > 0 {
+		attrs = append(attrs, slog.Duration("first_byte", t.firstByte))
+	}
+	if t.gotConn {
+		attrs = append(attrs, slog.Bool("reused", t.reused))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,135 @@
+package slogformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPTransport(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	base := server.Client().Transport.(*http.Transport)
+	defer base.CloseIdleConnections()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := &http.Client{
+		Transport: NewHTTPTransport(logger, HTTPTransportOptions{
+			Transport: base,
+			Request:   HTTPRequestFormatterOptions{Body: HTTPBodyOptions{MaxSize: 1024}},
+			Response:  HTTPResponseFormatterOptions{Body: HTTPBodyOptions{MaxSize: 1024}},
+			WithTrace: true,
+		}),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/users?token=abcd", strings.NewReader(`{"name":"john","password":"secret"}`))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abcd")
+	body := req.Body
+
+	res, err := client.Do(req)
+	is.NoError(err)
+	defer res.Body.Close()
+
+	// the request is not modified and both bodies are still readable
+	is.Equal(body, req.Body)
+	is.Same(req, res.Request)
+	output, err := io.ReadAll(res.Body)
+	is.NoError(err)
+	is.Equal(`{"name":"john","password":"secret"}`, string(output))
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &doc))
+
+	is.Equal("INFO", doc["level"])
+	is.Equal("http request", doc["msg"])
+	is.IsType(float64(0), doc["latency"])
+
+	request, ok := doc["request"].(map[string]any)
+	is.True(ok)
+	is.Equal("POST", request["method"])
+	is.Equal(server.URL+"/users?token=*******", request["url"].(map[string]any)["url"])
+	is.Equal("*******", request["headers"].(map[string]any)["Authorization"])
+	is.Equal("*******", request["body"].(map[string]any)["content"].(map[string]any)["password"])
+
+	response, ok := doc["response"].(map[string]any)
+	is.True(ok)
+	is.Equal(float64(201), response["status"])
+	is.Equal("*******", response["body"].(map[string]any)["content"].(map[string]any)["password"])
+
+	timings, ok := doc["timings"].(map[string]any)
+	is.True(ok)
+	is.Contains(timings, "connect")
+	is.Contains(timings, "tls_handshake")
+	is.Contains(timings, "first_byte")
+	is.Equal(false, timings["reused"])
+}
+
+func TestNewHTTPTransport_Error(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	base := &http.Transport{}
+	defer base.CloseIdleConnections()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := &http.Client{
+		Transport: NewHTTPTransport(logger, HTTPTransportOptions{Transport: base, Message: "outgoing call"}),
+	}
+
+	_, err := client.Get(url)
+	is.Error(err)
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &doc))
+
+	is.Equal("ERROR", doc["level"])
+	is.Equal("outgoing call", doc["msg"])
+	is.Contains(doc["error"], "connection refused")
+	is.NotContains(doc, "response")
+	is.NotContains(doc, "timings")
+}
+
+func TestNewHTTPTransport_Disabled(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	base := &http.Transport{}
+	defer base.CloseIdleConnections()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	client := &http.Client{Transport: NewHTTPTransport(logger, HTTPTransportOptions{Transport: base})}
+
+	res, err := client.Get(server.URL)
+	is.NoError(err)
+	is.NoError(res.Body.Close())
+	is.Equal(http.StatusNotFound, res.StatusCode)
+	is.Empty(buf.String())
+}
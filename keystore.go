@@ -0,0 +1,251 @@
+package slogformatter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrSubjectKeyNotFound is returned by KeyStore.Key when a key ID is unknown,
+// for instance because the key of the subject has been deleted.
+var ErrSubjectKeyNotFound = errors.New("slog-formatter: subject key not found")
+
+// KeyStore stores the data keys of subjects, for CryptoShreddingFormatter.
+// Each key has an opaque ID, written in the ciphertexts instead of the subject.
+// Deleting the key of a subject makes its encrypted data unreadable, even when
+// a new key is created for the subject later on.
+// Implementations must be safe for concurrent use.
+type KeyStore interface {
+	// GetOrCreateKey returns the key of a subject and its ID, creating a random
+	// 32 bytes key with a new random ID when the subject has none. Key IDs may
+	// only contain letters, digits and "-", and must not reveal the subject.
+	GetOrCreateKey(subject string) (keyID string, key []byte, err error)
+	// Key returns the key of a key ID, or ErrSubjectKeyNotFound.
+	Key(keyID string) ([]byte, error)
+	// DeleteKey deletes the key of a subject. Deleting a missing key is not an error.
+	DeleteKey(subject string) error
+}
+
+// newDataKey returns a random 32 bytes key and a random 128 bits hex ID.
+func newDataKey() (string, []byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", nil, err
+	}
+
+	return hex.EncodeToString(id), key, nil
+}
+
+// subjectKey is a data key and its ID.
+type subjectKey struct {
+	ID  string `json:"id"`
+	Key []byte `json:"key"`
+}
+
+var _ KeyStore = (*InMemoryKeyStore)(nil)
+
+// InMemoryKeyStore is a KeyStore for tests: keys are lost when the process exits.
+type InMemoryKeyStore struct {
+	mu       sync.RWMutex
+	subjects map[string]subjectKey
+	// key ID -> key
+	keys map[string][]byte
+}
+
+// NewInMemoryKeyStore returns an empty InMemoryKeyStore.
+func NewInMemoryKeyStore() *InMemoryKeyStore {
+	return &InMemoryKeyStore{
+		subjects: map[string]subjectKey{},
+		keys:     map[string][]byte{},
+	}
+}
+
+// GetOrCreateKey implements KeyStore.
+func (s *InMemoryKeyStore) GetOrCreateKey(subject string) (string, []byte, error) {
+	if sk, ok := s.lookup(subject); ok {
+		return sk.ID, sk.Key, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sk, ok := s.subjects[subject]; ok {
+		return sk.ID, sk.Key, nil
+	}
+
+	id, key, err := newDataKey()
+	if err != nil {
+		return "", nil, err
+	}
+	s.set(subject, subjectKey{ID: id, Key: key})
+
+	return id, key, nil
+}
+
+// set must be called with the lock held.
+func (s *InMemoryKeyStore) set(subject string, sk subjectKey) {
+	s.subjects[subject] = sk
+	s.keys[sk.ID] = sk.Key
+}
+
+// Key implements KeyStore.
+func (s *InMemoryKeyStore) Key(keyID string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, ErrSubjectKeyNotFound
+	}
+	return key, nil
+}
+
+// DeleteKey implements KeyStore.
+func (s *InMemoryKeyStore) DeleteKey(subject string) error {
+	s.mu.Lock()
+	if sk, ok := s.subjects[subject]; ok {
+		delete(s.keys, sk.ID)
+		delete(s.subjects, subject)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *InMemoryKeyStore) lookup(subject string) (subjectKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sk, ok := s.subjects[subject]
+	return sk, ok
+}
+
+var _ KeyStore = (*FileKeyStore)(nil)
+
+// FileKeyStore is a KeyStore for small deployments, persisting keys in a JSON
+// file mapping subjects to key IDs and base64-encoded keys, such as
+// {"42": {"id": "9f86d081884c7d65", "key": "..."}}. The file is rewritten atomically
+// on each change, with 0600 permissions. Deleted keys may survive in backups of
+// the file.
+type FileKeyStore struct {
+	path  string
+	store *InMemoryKeyStore
+	// serializes the writes of the file
+	mu sync.Mutex
+}
+
+// NewFileKeyStore loads the keys of the file, if it exists.
+func NewFileKeyStore(path string) (*FileKeyStore, error) {
+	s := &FileKeyStore{
+		path:  path,
+		store: NewInMemoryKeyStore(),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects map[string]subjectKey
+	if err := json.Unmarshal(data, &subjects); err != nil {
+		return nil, errors.New("slog-formatter: invalid key store file: " + err.Error())
+	}
+	for subject, sk := range subjects {
+		if sk.ID == "" || !isKeyID(sk.ID) || len(sk.Key) == 0 {
+			return nil, errors.New("slog-formatter: invalid key store file: invalid key of subject " + subject)
+		}
+		s.store.set(subject, sk)
+	}
+
+	return s, nil
+}
+
+// GetOrCreateKey implements KeyStore.
+func (s *FileKeyStore) GetOrCreateKey(subject string) (string, []byte, error) {
+	if sk, ok := s.store.lookup(subject); ok {
+		return sk.ID, sk.Key, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the key may have been created while waiting for the lock
+	if sk, ok := s.store.lookup(subject); ok {
+		return sk.ID, sk.Key, nil
+	}
+
+	id, key, err := s.store.GetOrCreateKey(subject)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.save(); err != nil {
+		_ = s.store.DeleteKey(subject)
+		return "", nil, err
+	}
+
+	return id, key, nil
+}
+
+// Key implements KeyStore.
+func (s *FileKeyStore) Key(keyID string) ([]byte, error) {
+	return s.store.Key(keyID)
+}
+
+// DeleteKey implements KeyStore.
+func (s *FileKeyStore) DeleteKey(subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.store.lookup(subject); !ok {
+		return nil
+	}
+
+	_ = s.store.DeleteKey(subject)
+	return s.save()
+}
+
+// save writes the keys to a temporary file, then renames it over the file.
+func (s *FileKeyStore) save() error {
+	s.store.mu.RLock()
+	data, err := json.Marshal(s.store.subjects)
+	s.store.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
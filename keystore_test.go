@@ -0,0 +1,148 @@
+package slogformatter
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryKeyStore(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	store := NewInMemoryKeyStore()
+
+	_, err := store.Key("42")
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+
+	id, key, err := store.GetOrCreateKey("42")
+	is.NoError(err)
+	is.Len(key, 32)
+	is.Len(id, 32)
+	is.True(isKeyID(id))
+
+	sameID, same, err := store.GetOrCreateKey("42")
+	is.NoError(err)
+	is.Equal(id, sameID)
+	is.Equal(key, same)
+
+	found, err := store.Key(id)
+	is.NoError(err)
+	is.Equal(key, found)
+	_, err = store.Key("42")
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+
+	otherID, other, err := store.GetOrCreateKey("43")
+	is.NoError(err)
+	is.NotEqual(id, otherID)
+	is.NotEqual(key, other)
+
+	is.NoError(store.DeleteKey("42"))
+	is.NoError(store.DeleteKey("42"))
+	_, err = store.Key(id)
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+
+	// a new key has a new ID
+	newID, _, err := store.GetOrCreateKey("42")
+	is.NoError(err)
+	is.NotEqual(id, newID)
+	_, err = store.Key(id)
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+}
+
+func TestInMemoryKeyStore_Concurrency(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	store := NewInMemoryKeyStore()
+
+	ids := make([]string, 10)
+	keys := make([][]byte, 10)
+	var wg sync.WaitGroup
+	for i := range keys {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], keys[i], _ = store.GetOrCreateKey("42")
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range keys {
+		is.Equal(ids[0], ids[i])
+		is.Equal(keys[0], keys[i])
+	}
+}
+
+func TestFileKeyStore(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	store, err := NewFileKeyStore(path)
+	is.NoError(err)
+
+	id, key, err := store.GetOrCreateKey("42")
+	is.NoError(err)
+	is.Len(key, 32)
+	otherID, _, err := store.GetOrCreateKey("43")
+	is.NoError(err)
+
+	info, err := os.Stat(path)
+	is.NoError(err)
+	is.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	// reload
+	reloaded, err := NewFileKeyStore(path)
+	is.NoError(err)
+	same, err := reloaded.Key(id)
+	is.NoError(err)
+	is.Equal(key, same)
+	sameID, _, err := reloaded.GetOrCreateKey("42")
+	is.NoError(err)
+	is.Equal(id, sameID)
+
+	is.NoError(reloaded.DeleteKey("42"))
+	is.NoError(reloaded.DeleteKey("42"))
+
+	reloaded, err = NewFileKeyStore(path)
+	is.NoError(err)
+	_, err = reloaded.Key(id)
+	is.ErrorIs(err, ErrSubjectKeyNotFound)
+	_, err = reloaded.Key(otherID)
+	is.NoError(err)
+
+	// no temporary file left
+	entries, err := os.ReadDir(filepath.Dir(path))
+	is.NoError(err)
+	is.Len(entries, 1)
+}
+
+func TestFileKeyStore_InvalidFile(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "invalid.json")
+	is.NoError(os.WriteFile(path, []byte("[]"), 0o600))
+	_, err := NewFileKeyStore(path)
+	is.Error(err)
+
+	path = filepath.Join(dir, "invalid-key.json")
+	is.NoError(os.WriteFile(path, []byte(`{"42":{"id":"abcd","key":"!!!"}}`), 0o600))
+	_, err = NewFileKeyStore(path)
+	is.Error(err)
+
+	path = filepath.Join(dir, "invalid-id.json")
+	is.NoError(os.WriteFile(path, []byte(`{"42":{"id":"a:b","key":"MDEyMzQ1Njc4OWFiY2RlZg=="}}`), 0o600))
+	_, err = NewFileKeyStore(path)
+	is.Error(err)
+
+	_, err = NewFileKeyStore(filepath.Join(dir, "missing", "keys.json"))
+	is.NoError(err)
+}
@@ -8,3 +8,8 @@ import (
 func NewFormatterMiddleware(formatters ...Formatter) slogmulti.Middleware {
 	return NewFormatterHandler(formatters...)
 }
+
+// NewFormatterMiddlewareWithOptions returns slog-multi middleware.
+func NewFormatterMiddlewareWithOptions(opts FormatterHandlerOptions) slogmulti.Middleware {
+	return NewFormatterHandlerWithOptions(opts)
+}
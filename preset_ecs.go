@@ -0,0 +1,84 @@
+package slogformatter
+
+import (
+	"strings"
+
+	"log/slog"
+)
+
+// ECSPreset bundles the formatters and the slog.HandlerOptions.ReplaceAttr function
+// producing Elastic Common Schema (ECS) documents.
+//
+// Requests and responses must be logged under an empty key, so that their
+// fields are inlined at the root of the document. Errors are logged under ErrorKey.
+//
+// Example:
+//
+//	preset := slogformatter.ECSPreset{}
+//	logger := slog.New(
+//	    slogformatter.NewFormatterHandler(preset.Formatters()...)(
+//	        slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: preset.ReplaceAttr}),
+//	    ),
+//	)
+//
+//	logger.Error("request failed", slog.Any("", req), slog.Any("", res), slog.Any("error", err))
+type ECSPreset struct {
+	// ErrorKey is the key of error attributes. Default: "error".
+	ErrorKey string
+	// Request configures the *http.Request formatter. Schema is always HTTPSchemaECS.
+	Request HTTPRequestFormatterOptions
+	// Response configures the *http.Response formatter. Schema is always HTTPSchemaECS.
+	Response HTTPResponseFormatterOptions
+	// Error configures the error formatter. Schema is always ErrorSchemaECS.
+	Error ErrorFormatterOptions
+}
+
+// Formatters returns the formatters for *http.Request, *http.Response and errors.
+func (p ECSPreset) Formatters() []Formatter {
+	errorKey := p.ErrorKey
+	if errorKey == "" {
+		errorKey = "error"
+	}
+
+	p.Request.Schema = HTTPSchemaECS
+	p.Response.Schema = HTTPSchemaECS
+	p.Error.Schema = ErrorSchemaECS
+
+	return []Formatter{
+		HTTPRequestFormatterWithOptions(p.Request),
+		HTTPResponseFormatterWithOptions(p.Response),
+		ErrorFormatterWithOptions(errorKey, p.Error),
+	}
+}
+
+// ReplaceAttr renames the built-in attributes of the record: "time" becomes
+// "@timestamp", "level" becomes "log.level", "msg" becomes "message" and
+// "source" becomes "log.origin".
+func (p ECSPreset) ReplaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	switch attr.Key {
+	case slog.TimeKey:
+		attr.Key = "@timestamp"
+	case slog.LevelKey:
+		attr.Key = "log.level"
+		if level, ok := attr.Value.Any().(slog.Level); ok {
+			attr.Value = slog.StringValue(strings.ToLower(level.String()))
+		}
+	case slog.MessageKey:
+		attr.Key = "message"
+	case slog.SourceKey:
+		if source, ok := attr.Value.Any().(*slog.Source); ok {
+			return slog.Group(
+				"log.origin",
+				slog.String("file.name", source.File),
+				slog.Int("file.line", source.Line),
+				slog.String("function", source.Function),
+			)
+		}
+	}
+
+	return attr
+}
@@ -0,0 +1,147 @@
+package slogformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestECSPreset(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	preset := ECSPreset{}
+
+	var buf bytes.Buffer
+	logger := slog.New(
+		NewFormatterHandler(preset.Formatters()...)(
+			slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, ReplaceAttr: preset.ReplaceAttr}),
+		),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com:8443/search?q=slog", nil)
+	is.NoError(err)
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	res := &http.Response{StatusCode: 503, ContentLength: 12, Header: http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}}}
+
+	logger.Warn("request failed", slog.Any("", req), slog.Any("", res), slog.Any("error", errors.New("boom")))
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &doc))
+
+	is.Contains(doc, "@timestamp")
+	is.Equal("warn", doc["log.level"])
+	is.Equal("request failed", doc["message"])
+	is.Contains(doc["log.origin"], "file.name")
+	is.NotContains(doc, "time")
+	is.NotContains(doc, "level")
+	is.NotContains(doc, "msg")
+
+	is.Equal("GET", doc["http.request.method"])
+	is.Equal("https://example.com:8443/search?q=slog", doc["url.original"])
+	is.Equal("example.com", doc["url.domain"])
+	is.Equal(float64(8443), doc["url.port"])
+	is.Equal("curl/8.4.0", doc["user_agent.original"])
+	is.Equal(float64(503), doc["http.response.status_code"])
+	is.Equal(float64(12), doc["http.response.body.bytes"])
+	is.Equal("text/plain", doc["http.response.mime_type"])
+
+	errorDoc, ok := doc["error"].(map[string]any)
+	is.True(ok)
+	is.Equal("boom", errorDoc["message"])
+	is.Equal("*errors.errorString", errorDoc["type"])
+	is.IsType("", errorDoc["stack_trace"])
+}
+
+func TestHTTPRequestFormatterWithOptions_ECSBody(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/login", bytes.NewBufferString(`{"user":"john","password":"secret"}`))
+	is.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Schema:       HTTPSchemaECS,
+		WithProtocol: true,
+		Body:         HTTPBodyOptions{MaxSize: 1024},
+	})
+
+	val, ok := formatter(nil, slog.Any("", req))
+	is.True(ok)
+
+	fields := map[string]slog.Value{}
+	for _, a := range val.Group() {
+		fields[a.Key] = a.Value
+	}
+	is.Equal("application/json", fields["http.request.mime_type"].String())
+	is.Equal("1.1", fields["http.version"].String())
+	is.Equal(int64(35), fields["http.request.body.bytes"].Int64())
+	is.Equal(`{"password":"*******","user":"john"}`, fields["http.request.body.content"].String())
+}
+
+func TestErrorFormatterWithOptions_ECSSchema(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	formatter := ErrorFormatterWithOptions("error", ErrorFormatterOptions{
+		Schema:         ErrorSchemaECS,
+		StacktraceMode: StacktraceModeFrames,
+	})
+
+	val, ok := formatter(nil, slog.Any("error", errors.New("boom")))
+	is.True(ok)
+
+	attrs := val.Group()
+	is.Len(attrs, 3)
+	is.Equal(slog.String("message", "boom"), attrs[0])
+	is.Equal(slog.String("type", "*errors.errorString"), attrs[1])
+	is.Equal("stack_trace", attrs[2].Key)
+	is.Equal(slog.KindString, attrs[2].Value.Kind())
+}
+
+func TestHTTPRequestFormatterWithOptions_ECSCustomFields(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/users/42", nil)
+	is.NoError(err)
+	req.Header.Set("Accept", "text/html")
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	formatter := HTTPRequestFormatterWithOptions(HTTPRequestFormatterOptions{
+		Schema:    HTTPSchemaECS,
+		WithRoute: true,
+		Cookies:   HTTPCookiesOptions{Enabled: true},
+	})
+
+	val, ok := formatter(nil, slog.Any("", req))
+	is.True(ok)
+
+	keys := []string{}
+	for _, a := range val.Group() {
+		keys = append(keys, a.Key)
+	}
+	is.Contains(keys, "custom.http.request.headers")
+	is.Contains(keys, "custom.http.request.cookies")
+	is.Contains(keys, "custom.http.route")
+	is.NotContains(keys, "http.request.headers")
+	is.NotContains(keys, "http.request.cookies")
+	is.NotContains(keys, "url.route")
+
+	res := &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": []string{"text/plain"}}}
+	val, ok = HTTPResponseFormatterWithOptions(HTTPResponseFormatterOptions{Schema: HTTPSchemaECS})(nil, slog.Any("", res))
+	is.True(ok)
+
+	keys = []string{}
+	for _, a := range val.Group() {
+		keys = append(keys, a.Key)
+	}
+	is.Contains(keys, "custom.http.response.headers")
+	is.NotContains(keys, "http.response.headers")
+}
@@ -0,0 +1,155 @@
+package slogformatter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+)
+
+// GCPHTTPRequest pairs a request, its response and the latency of the exchange.
+// GCPPreset renders it as a single Cloud Logging "httpRequest" object.
+type GCPHTTPRequest struct {
+	Request  *http.Request
+	Response *http.Response
+	Latency  time.Duration
+}
+
+// GCPPreset bundles the formatters and the slog.HandlerOptions.ReplaceAttr function
+// producing Google Cloud Logging structured logs.
+// See https://cloud.google.com/logging/docs/structured-logging.
+//
+// Log a GCPHTTPRequest under the "httpRequest" key. Errors must be logged under
+// an empty key, so that the Error Reporting fields are inlined at the root of the
+// log entry. Trace and span identifiers are logged under TraceKey and SpanIDKey.
+//
+// Example:
+//
+//	preset := slogformatter.GCPPreset{ProjectID: "my-project"}
+//	logger := slog.New(
+//	    slogformatter.NewFormatterHandler(preset.Formatters()...)(
+//	        slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: true, ReplaceAttr: preset.ReplaceAttr}),
+//	    ),
+//	)
+//
+//	logger.Error(
+//	    "request failed",
+//	    slog.Any("httpRequest", slogformatter.GCPHTTPRequest{Request: req, Response: res, Latency: time.Since(start)}),
+//	    slog.String("trace", traceID),
+//	    slog.Any("", err),
+//	)
+type GCPPreset struct {
+	// ProjectID prefixes trace identifiers with "projects/<ProjectID>/traces/".
+	ProjectID string
+	// TraceKey is the key of the trace identifier attribute. Default: "trace".
+	TraceKey string
+	// SpanIDKey is the key of the span identifier attribute. Default: "span_id".
+	SpanIDKey string
+	// Request configures the *http.Request formatter. Schema is always HTTPSchemaGCP.
+	Request HTTPRequestFormatterOptions
+	// Response configures the *http.Response formatter. Schema is always HTTPSchemaGCP.
+	Response HTTPResponseFormatterOptions
+}
+
+// Formatters returns the formatters for GCPHTTPRequest, *http.Request, *http.Response and errors.
+func (p GCPPreset) Formatters() []Formatter {
+	p.Request.Schema = HTTPSchemaGCP
+	p.Response.Schema = HTTPSchemaGCP
+
+	requests := newHTTPRequestFormatter(p.Request)
+	responses := newHTTPResponseFormatter(p.Response)
+
+	return []Formatter{
+		FormatByType(func(exchange GCPHTTPRequest) slog.Value {
+			attrs := []slog.Attr{}
+			if exchange.Request != nil {
+				attrs = append(attrs, requests.gcpAttrs(exchange.Request)...)
+			}
+			if exchange.Response != nil {
+				attrs = append(attrs, responses.gcpAttrs(exchange.Response)...)
+			}
+			if exchange.Latency > 0 {
+				attrs = append(attrs, slog.String("latency", gcpDuration(exchange.Latency)))
+			}
+			return slog.GroupValue(attrs...)
+		}),
+		HTTPRequestFormatterWithOptions(p.Request),
+		HTTPResponseFormatterWithOptions(p.Response),
+		ErrorFormatterWithOptions("", ErrorFormatterOptions{Schema: ErrorSchemaGCP}),
+	}
+}
+
+// ReplaceAttr renames the built-in attributes of the record: "level" becomes
+// "severity", "msg" becomes "message" and "source" becomes
+// "logging.googleapis.com/sourceLocation". Trace and span identifiers are moved
+// to "logging.googleapis.com/trace" and "logging.googleapis.com/spanId".
+func (p GCPPreset) ReplaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	traceKey := p.TraceKey
+	if traceKey == "" {
+		traceKey = "trace"
+	}
+	spanIDKey := p.SpanIDKey
+	if spanIDKey == "" {
+		spanIDKey = "span_id"
+	}
+
+	switch attr.Key {
+	case slog.LevelKey:
+		if level, ok := attr.Value.Any().(slog.Level); ok {
+			return slog.String("severity", gcpSeverity(level))
+		}
+	case slog.MessageKey:
+		attr.Key = "message"
+	case slog.SourceKey:
+		if source, ok := attr.Value.Any().(*slog.Source); ok {
+			return slog.Group(
+				"logging.googleapis.com/sourceLocation",
+				slog.String("file", source.File),
+				slog.String("line", strconv.Itoa(source.Line)),
+				slog.String("function", source.Function),
+			)
+		}
+	case traceKey:
+		trace := attr.Value.String()
+		if p.ProjectID != "" && !strings.HasPrefix(trace, "projects/") {
+			trace = "projects/" + p.ProjectID + "/traces/" + trace
+		}
+		return slog.String("logging.googleapis.com/trace", trace)
+	case spanIDKey:
+		return slog.String("logging.googleapis.com/spanId", attr.Value.String())
+	}
+
+	return attr
+}
+
+// gcpSeverity maps slog levels to Cloud Logging severities. Levels above
+// slog.LevelError map to CRITICAL, ALERT and EMERGENCY, in steps of 4.
+func gcpSeverity(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARNING"
+	case level < slog.LevelError+4:
+		return "ERROR"
+	case level < slog.LevelError+8:
+		return "CRITICAL"
+	case level < slog.LevelError+12:
+		return "ALERT"
+	default:
+		return "EMERGENCY"
+	}
+}
+
+// gcpDuration formats a duration with the proto3 JSON mapping, such as "1.5s".
+func gcpDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
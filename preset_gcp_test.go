@@ -0,0 +1,94 @@
+package slogformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPPreset(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	preset := GCPPreset{
+		ProjectID: "my-project",
+		Request:   HTTPRequestFormatterOptions{ClientIP: HTTPClientIPOptions{Enabled: true}},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(
+		NewFormatterHandler(preset.Formatters()...)(
+			slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, ReplaceAttr: preset.ReplaceAttr}),
+		),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/search?q=slog&token=secret", nil)
+	is.NoError(err)
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	req.Header.Set("Referer", "https://example.com/?api_key=abcd")
+	req.RemoteAddr = "203.0.113.7:4242"
+	res := &http.Response{StatusCode: 503, ContentLength: 12}
+
+	logger.Error(
+		"request failed",
+		slog.Any("httpRequest", GCPHTTPRequest{Request: req, Response: res, Latency: 1500 * time.Millisecond}),
+		slog.String("trace", "4bf92f3577b34da6a3ce929d0e0e4736"),
+		slog.String("span_id", "00f067aa0ba902b7"),
+		slog.Any("", errors.New("boom")),
+	)
+
+	var doc map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &doc))
+
+	is.Equal("ERROR", doc["severity"])
+	is.Equal("request failed", doc["message"])
+	is.NotContains(doc, "level")
+	is.NotContains(doc, "msg")
+
+	source, ok := doc["logging.googleapis.com/sourceLocation"].(map[string]any)
+	is.True(ok)
+	is.Contains(source["file"], "preset_gcp_test.go")
+	is.IsType("", source["line"])
+
+	is.Equal("projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736", doc["logging.googleapis.com/trace"])
+	is.Equal("00f067aa0ba902b7", doc["logging.googleapis.com/spanId"])
+
+	is.Equal(map[string]any{
+		"requestMethod": "POST",
+		"requestUrl":    "https://example.com/search?q=slog&token=*******",
+		"requestSize":   "0",
+		"userAgent":     "curl/8.4.0",
+		"referer":       "https://example.com/?api_key=*******",
+		"remoteIp":      "203.0.113.7",
+		"protocol":      "HTTP/1.1",
+		"status":        float64(503),
+		"responseSize":  "12",
+		"latency":       "1.5s",
+	}, doc["httpRequest"])
+
+	is.Equal("type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent", doc["@type"])
+	is.Equal("boom", doc["error_message"])
+	is.Equal("*errors.errorString", doc["error_type"])
+	stack, _ := doc["stack_trace"].(string)
+	is.True(strings.HasPrefix(stack, "goroutine "))
+}
+
+func TestGCPSeverity(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	is.Equal("DEBUG", gcpSeverity(slog.LevelDebug))
+	is.Equal("INFO", gcpSeverity(slog.LevelInfo))
+	is.Equal("WARNING", gcpSeverity(slog.LevelWarn))
+	is.Equal("ERROR", gcpSeverity(slog.LevelError))
+	is.Equal("CRITICAL", gcpSeverity(slog.LevelError+4))
+	is.Equal("ALERT", gcpSeverity(slog.LevelError+8))
+	is.Equal("EMERGENCY", gcpSeverity(slog.LevelError+12))
+}
@@ -0,0 +1,224 @@
+package slogformatter
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"log/slog"
+)
+
+// redactionAuditMaxEntries bounds the number of (rule, path) counters, since
+// attribute keys may be dynamic. Further paths are counted under
+// RedactionOtherPath.
+const redactionAuditMaxEntries = 1024
+
+// RedactionOtherPath is the path of the redactions counted once the audit holds
+// too many distinct paths.
+const RedactionOtherPath = "<other>"
+
+// RedactionAudit counts the redactions of the formatters sharing it, by rule and
+// attribute path. It is safe for concurrent use. A nil *RedactionAudit counts nothing.
+//
+// Rules are named after the formatter that redacted the value:
+//
+//   - "pii" and "pii:<pattern>" for PIIFormatterWithOptions, depending on the matching PIIRule;
+//   - "pii_scanner:<detector>" for PIIScannerFormatterWithOptions;
+//   - "ip_address" for IPAddressFormatterWithOptions;
+//   - "allowlist" for AllowlistFormatterWithOptions;
+//   - "pseudonymize" for PseudonymizeFormatter;
+//   - "encrypt" for EncryptFormatter;
+//   - "http_header", "cookie", "url_query", "url_userinfo" and "http_body" for the
+//     HTTP formatters, middleware and transport, and for URLFormatterWithOptions
+//     and HTTPCookieFormatterWithOptions.
+//
+// Paths are the keys of the enclosing groups and of the attribute, joined by ".".
+// The HTTP rules use paths relative to the request or response, such as
+// "headers.Authorization", "query.token" or "body.customer.password", whatever
+// the schema.
+type RedactionAudit struct {
+	mu       sync.RWMutex
+	counters map[redactionKey]*atomic.Uint64
+}
+
+type redactionKey struct {
+	rule string
+	path string
+}
+
+// RedactionCount is the number of redactions of a rule at a path.
+type RedactionCount struct {
+	Rule  string
+	Path  string
+	Count uint64
+}
+
+// RedactionStats is a snapshot of a RedactionAudit.
+type RedactionStats struct {
+	Total uint64
+	// Counts are sorted by rule and path.
+	Counts []RedactionCount
+}
+
+// ByRule returns the number of redactions of each rule.
+func (s RedactionStats) ByRule() map[string]uint64 {
+	output := map[string]uint64{}
+	for _, c := range s.Counts {
+		output[c.Rule] += c.Count
+	}
+	return output
+}
+
+// NewRedactionAudit returns an empty RedactionAudit.
+func NewRedactionAudit() *RedactionAudit {
+	return &RedactionAudit{
+		counters: map[redactionKey]*atomic.Uint64{},
+	}
+}
+
+func (a *RedactionAudit) add(rule string, path string) {
+	if a == nil {
+		return
+	}
+
+	key := redactionKey{rule: rule, path: path}
+
+	a.mu.RLock()
+	counter := a.counters[key]
+	a.mu.RUnlock()
+
+	if counter == nil {
+		a.mu.Lock()
+		counter = a.counters[key]
+		if counter == nil && len(a.counters) >= redactionAuditMaxEntries {
+			key.path = RedactionOtherPath
+			counter = a.counters[key]
+		}
+		if counter == nil {
+			counter = new(atomic.Uint64)
+			a.counters[key] = counter
+		}
+		a.mu.Unlock()
+	}
+
+	counter.Add(1)
+}
+
+// Stats returns a snapshot of the counters.
+func (a *RedactionAudit) Stats() RedactionStats {
+	if a == nil {
+		return RedactionStats{}
+	}
+
+	a.mu.RLock()
+	stats := RedactionStats{
+		Counts: make([]RedactionCount, 0, len(a.counters)),
+	}
+	for key, counter := range a.counters {
+		count := counter.Load()
+		stats.Total += count
+		stats.Counts = append(stats.Counts, RedactionCount{Rule: key.rule, Path: key.path, Count: count})
+	}
+	a.mu.RUnlock()
+
+	slices.SortFunc(stats.Counts, func(a, b RedactionCount) int {
+		if c := strings.Compare(a.Rule, b.Rule); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return stats
+}
+
+// Reset clears the counters.
+func (a *RedactionAudit) Reset() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.counters = map[redactionKey]*atomic.Uint64{}
+	a.mu.Unlock()
+}
+
+// LogSummary logs a "redaction summary" record every interval, with the number
+// of redactions since the previous summary, in total and by rule. The logger
+// should not redact the summary itself. Call stop to end the summaries.
+func (a *RedactionAudit) LogSummary(logger *slog.Logger, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		panic("slog-formatter: redaction summary interval must be positive")
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		previous := map[string]uint64{}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				previous = a.logSummary(logger, interval, previous)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
+
+func (a *RedactionAudit) logSummary(logger *slog.Logger, interval time.Duration, previous map[string]uint64) map[string]uint64 {
+	current := a.Stats().ByRule()
+
+	rules := make([]string, 0, len(current))
+	for rule := range current {
+		rules = append(rules, rule)
+	}
+	slices.Sort(rules)
+
+	var total uint64
+	attrs := make([]any, 0, len(rules))
+	for _, rule := range rules {
+		// counters only decrease on Reset
+		count := current[rule]
+		if count >= previous[rule] {
+			count -= previous[rule]
+		}
+		total += count
+		attrs = append(attrs, slog.Uint64(rule, count))
+	}
+
+	logger.LogAttrs(
+		context.Background(),
+		slog.LevelInfo,
+		"redaction summary",
+		slog.Duration("interval", interval),
+		slog.Uint64("total", total),
+		slog.Group("rules", attrs...),
+	)
+
+	return current
+}
+
+// joinPath returns the path of an attribute, from its enclosing groups and key.
+func joinPath(groups []string, key string) string {
+	if len(groups) == 0 {
+		return key
+	}
+	return strings.Join(groups, ".") + "." + key
+}